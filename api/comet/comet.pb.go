@@ -275,6 +275,84 @@ func (m *BroadcastRoomReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_BroadcastRoomReply proto.InternalMessageInfo
 
+type PushTopicReq struct {
+	Topic                string          `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Proto                *protocol.Proto `protobuf:"bytes,2,opt,name=proto,proto3" json:"proto,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *PushTopicReq) Reset()         { *m = PushTopicReq{} }
+func (m *PushTopicReq) String() string { return proto.CompactTextString(m) }
+func (*PushTopicReq) ProtoMessage()    {}
+func (*PushTopicReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_327b4a7d084564be, []int{6}
+}
+
+func (m *PushTopicReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushTopicReq.Unmarshal(m, b)
+}
+func (m *PushTopicReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushTopicReq.Marshal(b, m, deterministic)
+}
+func (m *PushTopicReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushTopicReq.Merge(m, src)
+}
+func (m *PushTopicReq) XXX_Size() int {
+	return xxx_messageInfo_PushTopicReq.Size(m)
+}
+func (m *PushTopicReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushTopicReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushTopicReq proto.InternalMessageInfo
+
+func (m *PushTopicReq) GetTopic() string {
+	if m != nil {
+		return m.Topic
+	}
+	return ""
+}
+
+func (m *PushTopicReq) GetProto() *protocol.Proto {
+	if m != nil {
+		return m.Proto
+	}
+	return nil
+}
+
+type PushTopicReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PushTopicReply) Reset()         { *m = PushTopicReply{} }
+func (m *PushTopicReply) String() string { return proto.CompactTextString(m) }
+func (*PushTopicReply) ProtoMessage()    {}
+func (*PushTopicReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_327b4a7d084564be, []int{7}
+}
+
+func (m *PushTopicReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PushTopicReply.Unmarshal(m, b)
+}
+func (m *PushTopicReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PushTopicReply.Marshal(b, m, deterministic)
+}
+func (m *PushTopicReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PushTopicReply.Merge(m, src)
+}
+func (m *PushTopicReply) XXX_Size() int {
+	return xxx_messageInfo_PushTopicReply.Size(m)
+}
+func (m *PushTopicReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_PushTopicReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PushTopicReply proto.InternalMessageInfo
+
 type RoomsReq struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -285,7 +363,7 @@ func (m *RoomsReq) Reset()         { *m = RoomsReq{} }
 func (m *RoomsReq) String() string { return proto.CompactTextString(m) }
 func (*RoomsReq) ProtoMessage()    {}
 func (*RoomsReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_327b4a7d084564be, []int{6}
+	return fileDescriptor_327b4a7d084564be, []int{8}
 }
 
 func (m *RoomsReq) XXX_Unmarshal(b []byte) error {
@@ -317,7 +395,7 @@ func (m *RoomsReply) Reset()         { *m = RoomsReply{} }
 func (m *RoomsReply) String() string { return proto.CompactTextString(m) }
 func (*RoomsReply) ProtoMessage()    {}
 func (*RoomsReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_327b4a7d084564be, []int{7}
+	return fileDescriptor_327b4a7d084564be, []int{9}
 }
 
 func (m *RoomsReply) XXX_Unmarshal(b []byte) error {
@@ -345,6 +423,186 @@ func (m *RoomsReply) GetRooms() map[string]bool {
 	return nil
 }
 
+type KickKeysReq struct {
+	Keys                 []string `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KickKeysReq) Reset()         { *m = KickKeysReq{} }
+func (m *KickKeysReq) String() string { return proto.CompactTextString(m) }
+func (*KickKeysReq) ProtoMessage()    {}
+func (*KickKeysReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_327b4a7d084564be, []int{10}
+}
+
+func (m *KickKeysReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KickKeysReq.Unmarshal(m, b)
+}
+func (m *KickKeysReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KickKeysReq.Marshal(b, m, deterministic)
+}
+func (m *KickKeysReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KickKeysReq.Merge(m, src)
+}
+func (m *KickKeysReq) XXX_Size() int {
+	return xxx_messageInfo_KickKeysReq.Size(m)
+}
+func (m *KickKeysReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_KickKeysReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KickKeysReq proto.InternalMessageInfo
+
+func (m *KickKeysReq) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *KickKeysReq) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type KickRoomReq struct {
+	RoomID               string   `protobuf:"bytes,1,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KickRoomReq) Reset()         { *m = KickRoomReq{} }
+func (m *KickRoomReq) String() string { return proto.CompactTextString(m) }
+func (*KickRoomReq) ProtoMessage()    {}
+func (*KickRoomReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_327b4a7d084564be, []int{11}
+}
+
+func (m *KickRoomReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KickRoomReq.Unmarshal(m, b)
+}
+func (m *KickRoomReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KickRoomReq.Marshal(b, m, deterministic)
+}
+func (m *KickRoomReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KickRoomReq.Merge(m, src)
+}
+func (m *KickRoomReq) XXX_Size() int {
+	return xxx_messageInfo_KickRoomReq.Size(m)
+}
+func (m *KickRoomReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_KickRoomReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KickRoomReq proto.InternalMessageInfo
+
+func (m *KickRoomReq) GetRoomID() string {
+	if m != nil {
+		return m.RoomID
+	}
+	return ""
+}
+
+func (m *KickRoomReq) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type KickIPReq struct {
+	Ip                   string   `protobuf:"bytes,1,opt,name=ip,proto3" json:"ip,omitempty"`
+	Reason               string   `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KickIPReq) Reset()         { *m = KickIPReq{} }
+func (m *KickIPReq) String() string { return proto.CompactTextString(m) }
+func (*KickIPReq) ProtoMessage()    {}
+func (*KickIPReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_327b4a7d084564be, []int{12}
+}
+
+func (m *KickIPReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KickIPReq.Unmarshal(m, b)
+}
+func (m *KickIPReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KickIPReq.Marshal(b, m, deterministic)
+}
+func (m *KickIPReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KickIPReq.Merge(m, src)
+}
+func (m *KickIPReq) XXX_Size() int {
+	return xxx_messageInfo_KickIPReq.Size(m)
+}
+func (m *KickIPReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_KickIPReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KickIPReq proto.InternalMessageInfo
+
+func (m *KickIPReq) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+func (m *KickIPReq) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+type KickReply struct {
+	Count                int32    `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KickReply) Reset()         { *m = KickReply{} }
+func (m *KickReply) String() string { return proto.CompactTextString(m) }
+func (*KickReply) ProtoMessage()    {}
+func (*KickReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_327b4a7d084564be, []int{13}
+}
+
+func (m *KickReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KickReply.Unmarshal(m, b)
+}
+func (m *KickReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KickReply.Marshal(b, m, deterministic)
+}
+func (m *KickReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KickReply.Merge(m, src)
+}
+func (m *KickReply) XXX_Size() int {
+	return xxx_messageInfo_KickReply.Size(m)
+}
+func (m *KickReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_KickReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KickReply proto.InternalMessageInfo
+
+func (m *KickReply) GetCount() int32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*PushMsgReq)(nil), "goim.comet.PushMsgReq")
 	proto.RegisterType((*PushMsgReply)(nil), "goim.comet.PushMsgReply")
@@ -352,41 +610,55 @@ func init() {
 	proto.RegisterType((*BroadcastReply)(nil), "goim.comet.BroadcastReply")
 	proto.RegisterType((*BroadcastRoomReq)(nil), "goim.comet.BroadcastRoomReq")
 	proto.RegisterType((*BroadcastRoomReply)(nil), "goim.comet.BroadcastRoomReply")
+	proto.RegisterType((*PushTopicReq)(nil), "goim.comet.PushTopicReq")
+	proto.RegisterType((*PushTopicReply)(nil), "goim.comet.PushTopicReply")
 	proto.RegisterType((*RoomsReq)(nil), "goim.comet.RoomsReq")
 	proto.RegisterType((*RoomsReply)(nil), "goim.comet.RoomsReply")
 	proto.RegisterMapType((map[string]bool)(nil), "goim.comet.RoomsReply.RoomsEntry")
+	proto.RegisterType((*KickKeysReq)(nil), "goim.comet.KickKeysReq")
+	proto.RegisterType((*KickRoomReq)(nil), "goim.comet.KickRoomReq")
+	proto.RegisterType((*KickIPReq)(nil), "goim.comet.KickIPReq")
+	proto.RegisterType((*KickReply)(nil), "goim.comet.KickReply")
 }
 
 func init() { proto.RegisterFile("comet/comet.proto", fileDescriptor_327b4a7d084564be) }
 
 var fileDescriptor_327b4a7d084564be = []byte{
-	// 404 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x53, 0xcf, 0x4f, 0xe2, 0x40,
-	0x14, 0x4e, 0x61, 0xcb, 0x8f, 0x07, 0x4b, 0xd8, 0x49, 0x43, 0x9a, 0x66, 0xb3, 0x61, 0x7b, 0x62,
-	0xd7, 0xd8, 0x26, 0x18, 0x22, 0x91, 0x93, 0xa8, 0x07, 0x0f, 0x44, 0x32, 0x31, 0x1e, 0xbc, 0x15,
-	0x18, 0x01, 0x69, 0x9d, 0xd2, 0x16, 0x93, 0x9e, 0xfc, 0x0b, 0xfc, 0x9f, 0xcd, 0x9b, 0xa9, 0x6d,
-	0x31, 0x95, 0x44, 0x2f, 0xcd, 0xf7, 0xde, 0x7c, 0xf3, 0xbe, 0x6f, 0xbe, 0x07, 0xf0, 0x6b, 0xce,
-	0x3d, 0x16, 0xd9, 0xe2, 0x6b, 0xf9, 0x01, 0x8f, 0x38, 0x81, 0x25, 0x5f, 0x7b, 0x96, 0xe8, 0x18,
-	0x83, 0xe5, 0x3a, 0x5a, 0xed, 0x66, 0x58, 0xd9, 0xb7, 0x2c, 0x08, 0xe2, 0xe3, 0x89, 0xc3, 0x6d,
-	0x24, 0xd8, 0x8e, 0xbf, 0xb6, 0xc5, 0x85, 0x39, 0x77, 0x53, 0x20, 0x47, 0x98, 0x0f, 0x00, 0xd3,
-	0x5d, 0xb8, 0x9a, 0x84, 0x4b, 0xca, 0xb6, 0x84, 0xc0, 0x8f, 0x0d, 0x8b, 0x43, 0x5d, 0xe9, 0x96,
-	0x7b, 0x75, 0x2a, 0x30, 0xd1, 0xa1, 0x2a, 0xa8, 0x37, 0xbe, 0x5e, 0xee, 0x2a, 0x3d, 0x95, 0xbe,
-	0x97, 0xe4, 0x3f, 0xa8, 0x02, 0xea, 0xa5, 0xae, 0xd2, 0x6b, 0xf4, 0x35, 0x4b, 0xd8, 0x49, 0x05,
-	0xa6, 0x08, 0xa8, 0xa4, 0x98, 0x2d, 0x68, 0xa6, 0x3a, 0xbe, 0x1b, 0x9b, 0x8f, 0xd0, 0x1c, 0x07,
-	0xdc, 0x59, 0xcc, 0x9d, 0x30, 0x42, 0xe5, 0x9c, 0x8a, 0xf2, 0x6d, 0x15, 0xa2, 0x81, 0x1a, 0xfa,
-	0x8c, 0x2d, 0x12, 0xa7, 0xb2, 0x30, 0xdb, 0xd0, 0xca, 0x69, 0xa1, 0xfa, 0x1d, 0xb4, 0xb3, 0x0e,
-	0xe7, 0x1e, 0x3a, 0xe8, 0x40, 0x25, 0xe0, 0xdc, 0xbb, 0xbe, 0x14, 0x06, 0xea, 0x34, 0xa9, 0xbe,
-	0xf4, 0x4a, 0x0d, 0xc8, 0x87, 0xb9, 0xa8, 0x06, 0x50, 0xc3, 0x22, 0xa4, 0x6c, 0x6b, 0xbe, 0x00,
-	0x24, 0xd8, 0x77, 0x63, 0x72, 0x0a, 0x2a, 0xaa, 0xc8, 0xc0, 0x1b, 0xfd, 0xbf, 0x56, 0xb6, 0x50,
-	0x2b, 0xa3, 0x49, 0x78, 0xf5, 0x14, 0x05, 0x31, 0x95, 0x7c, 0x63, 0x98, 0x8c, 0x11, 0x4d, 0xd2,
-	0x86, 0xf2, 0x86, 0xc5, 0x89, 0x6f, 0x84, 0x18, 0xc4, 0xb3, 0xe3, 0xee, 0x98, 0x30, 0x5d, 0xa3,
-	0xb2, 0x38, 0x2b, 0x0d, 0x95, 0xfe, 0x6b, 0x09, 0xd4, 0x0b, 0x14, 0x20, 0x23, 0xa8, 0x26, 0x2b,
-	0x21, 0x9d, 0xbc, 0x70, 0xf6, 0x7b, 0x30, 0xf4, 0xc2, 0x3e, 0x3a, 0x3f, 0x87, 0x7a, 0xfa, 0x52,
-	0xb2, 0x47, 0xcb, 0xaf, 0xd5, 0x30, 0x3e, 0x39, 0xc1, 0x11, 0x13, 0xf8, 0xb9, 0x17, 0x16, 0xf9,
-	0x5d, 0x4c, 0x96, 0xfb, 0x31, 0xfe, 0x1c, 0x38, 0xc5, 0x71, 0x03, 0x50, 0x45, 0x24, 0x44, 0x2b,
-	0x48, 0x71, 0x6b, 0x74, 0x8a, 0xb3, 0x1d, 0x1f, 0xdd, 0xff, 0x3b, 0xfc, 0xcf, 0x11, 0x37, 0x46,
-	0xe2, 0x3b, 0xab, 0x88, 0x35, 0x9f, 0xbc, 0x05, 0x00, 0x00, 0xff, 0xff, 0xb3, 0xcb, 0xf3, 0x2a,
-	0x8c, 0x03, 0x00, 0x00,
+	// 541 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x54, 0xcf, 0x6f, 0xd3, 0x4c,
+	0x10, 0x95, 0x9d, 0x3a, 0x4d, 0x26, 0xfd, 0xa2, 0x7c, 0x2b, 0x13, 0xac, 0x15, 0x42, 0xa9, 0x4f,
+	0x01, 0x84, 0x2d, 0xa5, 0x2a, 0x94, 0x56, 0x1c, 0x28, 0x70, 0x88, 0xaa, 0x08, 0xcb, 0xaa, 0x38,
+	0x70, 0x73, 0xdd, 0x25, 0x35, 0xf9, 0xb1, 0x8e, 0xed, 0x20, 0xf9, 0x04, 0x7f, 0x3a, 0x9a, 0xdd,
+	0x8d, 0xed, 0x34, 0x71, 0x51, 0xb9, 0x44, 0x33, 0xbb, 0xef, 0xcd, 0x9b, 0x19, 0xbf, 0x0d, 0xfc,
+	0x1f, 0xf2, 0x05, 0xcb, 0x5c, 0xf1, 0xeb, 0xc4, 0x09, 0xcf, 0x38, 0x81, 0x29, 0x8f, 0x16, 0x8e,
+	0x38, 0xa1, 0xa7, 0xd3, 0x28, 0xbb, 0x5b, 0xdf, 0x60, 0xe6, 0x5e, 0xb3, 0x24, 0xc9, 0x5f, 0x4f,
+	0x02, 0xee, 0x22, 0xc0, 0x0d, 0xe2, 0xc8, 0x15, 0x84, 0x90, 0xcf, 0x8b, 0x40, 0x96, 0xb0, 0xbf,
+	0x03, 0x78, 0xeb, 0xf4, 0x6e, 0x92, 0x4e, 0x7d, 0xb6, 0x22, 0x04, 0x0e, 0x66, 0x2c, 0x4f, 0x2d,
+	0x6d, 0xd0, 0x18, 0xb6, 0x7d, 0x11, 0x13, 0x0b, 0x0e, 0x05, 0xf4, 0x4b, 0x6c, 0x35, 0x06, 0xda,
+	0xd0, 0xf0, 0x37, 0x29, 0x79, 0x09, 0x86, 0x08, 0x2d, 0x7d, 0xa0, 0x0d, 0x3b, 0x23, 0xd3, 0x11,
+	0xed, 0x14, 0x02, 0x1e, 0x06, 0xbe, 0x84, 0xd8, 0x5d, 0x38, 0x2a, 0x74, 0xe2, 0x79, 0x6e, 0xff,
+	0x80, 0xa3, 0xcb, 0x84, 0x07, 0xb7, 0x61, 0x90, 0x66, 0xa8, 0x5c, 0x51, 0xd1, 0xfe, 0x59, 0x85,
+	0x98, 0x60, 0xa4, 0x31, 0x63, 0xb7, 0xaa, 0x53, 0x99, 0xd8, 0x3d, 0xe8, 0x56, 0xb4, 0x50, 0xfd,
+	0x2b, 0xf4, 0xca, 0x13, 0xce, 0x17, 0xd8, 0x41, 0x1f, 0x9a, 0x09, 0xe7, 0x8b, 0xf1, 0x27, 0xd1,
+	0x40, 0xdb, 0x57, 0xd9, 0xa3, 0xa6, 0x34, 0x81, 0xdc, 0xab, 0x8b, 0x6a, 0x9e, 0x9c, 0xfd, 0x9a,
+	0xc7, 0x51, 0x88, 0x4a, 0x26, 0x18, 0x19, 0xc6, 0x4a, 0x48, 0x26, 0x8f, 0xd2, 0xe9, 0x41, 0xb7,
+	0x52, 0x11, 0x35, 0x00, 0x5a, 0x28, 0x98, 0xfa, 0x6c, 0x65, 0xff, 0x02, 0x50, 0x71, 0x3c, 0xcf,
+	0xc9, 0x5b, 0x30, 0x70, 0x12, 0xf9, 0x51, 0x3b, 0xa3, 0x63, 0xa7, 0x34, 0x8d, 0x53, 0xc2, 0x64,
+	0xf8, 0x79, 0x99, 0x25, 0xb9, 0x2f, 0xf1, 0xf4, 0x0c, 0xa0, 0x3c, 0x24, 0x3d, 0x68, 0xcc, 0x58,
+	0xae, 0x5a, 0xc6, 0x10, 0xc7, 0xf8, 0x19, 0xcc, 0xd7, 0x4c, 0x34, 0xdc, 0xf2, 0x65, 0x72, 0xae,
+	0x9f, 0x69, 0xf6, 0x3b, 0xe8, 0x5c, 0x45, 0xe1, 0xec, 0x8a, 0xe5, 0x69, 0x9d, 0xab, 0x70, 0xdb,
+	0x2c, 0x48, 0xf9, 0xd2, 0xd2, 0xd5, 0xb6, 0x45, 0x66, 0xbf, 0x97, 0xd4, 0xbf, 0x7d, 0x94, 0x3a,
+	0xfa, 0x09, 0xb4, 0x91, 0x3e, 0xf6, 0x90, 0xdc, 0x05, 0x3d, 0x8a, 0x15, 0x51, 0x8f, 0xe2, 0x5a,
+	0xd2, 0xb1, 0x24, 0xc9, 0x75, 0x99, 0x60, 0x84, 0x7c, 0xbd, 0xcc, 0x94, 0x0d, 0x65, 0x32, 0xfa,
+	0x7d, 0x00, 0xc6, 0x47, 0x5c, 0x19, 0xb9, 0x80, 0x43, 0x65, 0x64, 0xd2, 0xaf, 0xae, 0xb2, 0x7c,
+	0x45, 0xd4, 0xda, 0x7b, 0x8e, 0xc5, 0x3f, 0x40, 0xbb, 0xf0, 0x07, 0xd9, 0x82, 0x55, 0x1f, 0x03,
+	0xa5, 0x35, 0x37, 0x58, 0x62, 0x02, 0xff, 0x6d, 0x59, 0x8c, 0x3c, 0xdb, 0x0f, 0x96, 0x0b, 0xa4,
+	0xcf, 0x1f, 0xb8, 0x55, 0x1d, 0x15, 0x4e, 0x22, 0x3b, 0x8d, 0x6f, 0x2c, 0x4b, 0x69, 0xcd, 0x0d,
+	0x96, 0x38, 0x05, 0x43, 0xf8, 0x84, 0x98, 0x7b, 0xac, 0xb5, 0xa2, 0xfd, 0xfd, 0x86, 0x23, 0xe7,
+	0xd0, 0xda, 0x98, 0x84, 0x3c, 0xad, 0x62, 0x2a, 0xd6, 0xa1, 0x4f, 0xee, 0x5f, 0x6c, 0x71, 0xc5,
+	0xfc, 0x3b, 0xdc, 0xcd, 0xe8, 0x35, 0xdc, 0x37, 0xd0, 0x94, 0x16, 0x21, 0x3b, 0x80, 0xb1, 0x57,
+	0xcf, 0xbb, 0x7c, 0xf5, 0xed, 0xc5, 0xc3, 0x7f, 0xb1, 0x82, 0x70, 0x21, 0x7e, 0x6f, 0x9a, 0xe2,
+	0x9d, 0x9e, 0xfc, 0x19, 0x00, 0x71, 0x48, 0xb0, 0xb6, 0xb5, 0x05, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -407,8 +679,16 @@ type CometClient interface {
 	Broadcast(ctx context.Context, in *BroadcastReq, opts ...grpc.CallOption) (*BroadcastReply, error)
 	// BroadcastRoom broadcast to one room
 	BroadcastRoom(ctx context.Context, in *BroadcastRoomReq, opts ...grpc.CallOption) (*BroadcastRoomReply, error)
+	// PushTopic push to the subscribers of a topic
+	PushTopic(ctx context.Context, in *PushTopicReq, opts ...grpc.CallOption) (*PushTopicReply, error)
 	// Rooms get all rooms
 	Rooms(ctx context.Context, in *RoomsReq, opts ...grpc.CallOption) (*RoomsReply, error)
+	// KickKeys disconnect the keys
+	KickKeys(ctx context.Context, in *KickKeysReq, opts ...grpc.CallOption) (*KickReply, error)
+	// KickRoom disconnect all in the room
+	KickRoom(ctx context.Context, in *KickRoomReq, opts ...grpc.CallOption) (*KickReply, error)
+	// KickIP disconnect all of the ip or in the cidr
+	KickIP(ctx context.Context, in *KickIPReq, opts ...grpc.CallOption) (*KickReply, error)
 }
 
 type cometClient struct {
@@ -446,6 +726,15 @@ func (c *cometClient) BroadcastRoom(ctx context.Context, in *BroadcastRoomReq, o
 	return out, nil
 }
 
+func (c *cometClient) PushTopic(ctx context.Context, in *PushTopicReq, opts ...grpc.CallOption) (*PushTopicReply, error) {
+	out := new(PushTopicReply)
+	err := c.cc.Invoke(ctx, "/goim.comet.Comet/PushTopic", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *cometClient) Rooms(ctx context.Context, in *RoomsReq, opts ...grpc.CallOption) (*RoomsReply, error) {
 	out := new(RoomsReply)
 	err := c.cc.Invoke(ctx, "/goim.comet.Comet/Rooms", in, out, opts...)
@@ -455,6 +744,33 @@ func (c *cometClient) Rooms(ctx context.Context, in *RoomsReq, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *cometClient) KickKeys(ctx context.Context, in *KickKeysReq, opts ...grpc.CallOption) (*KickReply, error) {
+	out := new(KickReply)
+	err := c.cc.Invoke(ctx, "/goim.comet.Comet/KickKeys", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cometClient) KickRoom(ctx context.Context, in *KickRoomReq, opts ...grpc.CallOption) (*KickReply, error) {
+	out := new(KickReply)
+	err := c.cc.Invoke(ctx, "/goim.comet.Comet/KickRoom", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cometClient) KickIP(ctx context.Context, in *KickIPReq, opts ...grpc.CallOption) (*KickReply, error) {
+	out := new(KickReply)
+	err := c.cc.Invoke(ctx, "/goim.comet.Comet/KickIP", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CometServer is the server API for Comet service.
 type CometServer interface {
 	// PushMsg push by key or mid
@@ -463,8 +779,16 @@ type CometServer interface {
 	Broadcast(context.Context, *BroadcastReq) (*BroadcastReply, error)
 	// BroadcastRoom broadcast to one room
 	BroadcastRoom(context.Context, *BroadcastRoomReq) (*BroadcastRoomReply, error)
+	// PushTopic push to the subscribers of a topic
+	PushTopic(context.Context, *PushTopicReq) (*PushTopicReply, error)
 	// Rooms get all rooms
 	Rooms(context.Context, *RoomsReq) (*RoomsReply, error)
+	// KickKeys disconnect the keys
+	KickKeys(context.Context, *KickKeysReq) (*KickReply, error)
+	// KickRoom disconnect all in the room
+	KickRoom(context.Context, *KickRoomReq) (*KickReply, error)
+	// KickIP disconnect all of the ip or in the cidr
+	KickIP(context.Context, *KickIPReq) (*KickReply, error)
 }
 
 // UnimplementedCometServer can be embedded to have forward compatible implementations.
@@ -480,9 +804,21 @@ func (*UnimplementedCometServer) Broadcast(ctx context.Context, req *BroadcastRe
 func (*UnimplementedCometServer) BroadcastRoom(ctx context.Context, req *BroadcastRoomReq) (*BroadcastRoomReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BroadcastRoom not implemented")
 }
+func (*UnimplementedCometServer) PushTopic(ctx context.Context, req *PushTopicReq) (*PushTopicReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PushTopic not implemented")
+}
 func (*UnimplementedCometServer) Rooms(ctx context.Context, req *RoomsReq) (*RoomsReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Rooms not implemented")
 }
+func (*UnimplementedCometServer) KickKeys(ctx context.Context, req *KickKeysReq) (*KickReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickKeys not implemented")
+}
+func (*UnimplementedCometServer) KickRoom(ctx context.Context, req *KickRoomReq) (*KickReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickRoom not implemented")
+}
+func (*UnimplementedCometServer) KickIP(ctx context.Context, req *KickIPReq) (*KickReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KickIP not implemented")
+}
 
 func RegisterCometServer(s *grpc.Server, srv CometServer) {
 	s.RegisterService(&_Comet_serviceDesc, srv)
@@ -542,6 +878,24 @@ func _Comet_BroadcastRoom_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Comet_PushTopic_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PushTopicReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CometServer).PushTopic(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.comet.Comet/PushTopic",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CometServer).PushTopic(ctx, req.(*PushTopicReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Comet_Rooms_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(RoomsReq)
 	if err := dec(in); err != nil {
@@ -560,6 +914,60 @@ func _Comet_Rooms_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Comet_KickKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickKeysReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CometServer).KickKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.comet.Comet/KickKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CometServer).KickKeys(ctx, req.(*KickKeysReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Comet_KickRoom_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickRoomReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CometServer).KickRoom(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.comet.Comet/KickRoom",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CometServer).KickRoom(ctx, req.(*KickRoomReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Comet_KickIP_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KickIPReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CometServer).KickIP(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.comet.Comet/KickIP",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CometServer).KickIP(ctx, req.(*KickIPReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _Comet_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "goim.comet.Comet",
 	HandlerType: (*CometServer)(nil),
@@ -576,10 +984,26 @@ var _Comet_serviceDesc = grpc.ServiceDesc{
 			MethodName: "BroadcastRoom",
 			Handler:    _Comet_BroadcastRoom_Handler,
 		},
+		{
+			MethodName: "PushTopic",
+			Handler:    _Comet_PushTopic_Handler,
+		},
 		{
 			MethodName: "Rooms",
 			Handler:    _Comet_Rooms_Handler,
 		},
+		{
+			MethodName: "KickKeys",
+			Handler:    _Comet_KickKeys_Handler,
+		},
+		{
+			MethodName: "KickRoom",
+			Handler:    _Comet_KickRoom_Handler,
+		},
+		{
+			MethodName: "KickIP",
+			Handler:    _Comet_KickIP_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "comet/comet.proto",
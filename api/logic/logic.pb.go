@@ -31,18 +31,24 @@ const (
 	PushMsg_PUSH      PushMsg_Type = 0
 	PushMsg_ROOM      PushMsg_Type = 1
 	PushMsg_BROADCAST PushMsg_Type = 2
+	PushMsg_KICK      PushMsg_Type = 3
+	PushMsg_TOPIC     PushMsg_Type = 4
 )
 
 var PushMsg_Type_name = map[int32]string{
 	0: "PUSH",
 	1: "ROOM",
 	2: "BROADCAST",
+	3: "KICK",
+	4: "TOPIC",
 }
 
 var PushMsg_Type_value = map[string]int32{
 	"PUSH":      0,
 	"ROOM":      1,
 	"BROADCAST": 2,
+	"KICK":      3,
+	"TOPIC":     4,
 }
 
 func (x PushMsg_Type) String() string {
@@ -61,6 +67,10 @@ type PushMsg struct {
 	Room                 string       `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`
 	Keys                 []string     `protobuf:"bytes,6,rep,name=keys,proto3" json:"keys,omitempty"`
 	Msg                  []byte       `protobuf:"bytes,7,opt,name=msg,proto3" json:"msg,omitempty"`
+	Ip                   string       `protobuf:"bytes,8,opt,name=ip,proto3" json:"ip,omitempty"`
+	Topic                string       `protobuf:"bytes,9,opt,name=topic,proto3" json:"topic,omitempty"`
+	Seqs                 []int32      `protobuf:"varint,10,rep,packed,name=seqs,proto3" json:"seqs,omitempty"`
+	Trace                string       `protobuf:"bytes,11,opt,name=trace,proto3" json:"trace,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}     `json:"-"`
 	XXX_unrecognized     []byte       `json:"-"`
 	XXX_sizecache        int32        `json:"-"`
@@ -140,10 +150,118 @@ func (m *PushMsg) GetMsg() []byte {
 	return nil
 }
 
+func (m *PushMsg) GetIp() string {
+	if m != nil {
+		return m.Ip
+	}
+	return ""
+}
+
+func (m *PushMsg) GetTopic() string {
+	if m != nil {
+		return m.Topic
+	}
+	return ""
+}
+
+func (m *PushMsg) GetSeqs() []int32 {
+	if m != nil {
+		return m.Seqs
+	}
+	return nil
+}
+
+func (m *PushMsg) GetTrace() string {
+	if m != nil {
+		return m.Trace
+	}
+	return ""
+}
+
+type ReceiveMsg struct {
+	Mid                  int64           `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string          `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string          `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Room                 string          `protobuf:"bytes,4,opt,name=room,proto3" json:"room,omitempty"`
+	Timestamp            int64           `protobuf:"varint,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Proto                *protocol.Proto `protobuf:"bytes,6,opt,name=proto,proto3" json:"proto,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
+	XXX_unrecognized     []byte          `json:"-"`
+	XXX_sizecache        int32           `json:"-"`
+}
+
+func (m *ReceiveMsg) Reset()         { *m = ReceiveMsg{} }
+func (m *ReceiveMsg) String() string { return proto.CompactTextString(m) }
+func (*ReceiveMsg) ProtoMessage()    {}
+func (*ReceiveMsg) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{1}
+}
+
+func (m *ReceiveMsg) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReceiveMsg.Unmarshal(m, b)
+}
+func (m *ReceiveMsg) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReceiveMsg.Marshal(b, m, deterministic)
+}
+func (m *ReceiveMsg) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReceiveMsg.Merge(m, src)
+}
+func (m *ReceiveMsg) XXX_Size() int {
+	return xxx_messageInfo_ReceiveMsg.Size(m)
+}
+func (m *ReceiveMsg) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReceiveMsg.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReceiveMsg proto.InternalMessageInfo
+
+func (m *ReceiveMsg) GetMid() int64 {
+	if m != nil {
+		return m.Mid
+	}
+	return 0
+}
+
+func (m *ReceiveMsg) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ReceiveMsg) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *ReceiveMsg) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
+func (m *ReceiveMsg) GetTimestamp() int64 {
+	if m != nil {
+		return m.Timestamp
+	}
+	return 0
+}
+
+func (m *ReceiveMsg) GetProto() *protocol.Proto {
+	if m != nil {
+		return m.Proto
+	}
+	return nil
+}
+
 type ConnectReq struct {
 	Server               string   `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
 	Cookie               string   `protobuf:"bytes,2,opt,name=cookie,proto3" json:"cookie,omitempty"`
 	Token                []byte   `protobuf:"bytes,3,opt,name=token,proto3" json:"token,omitempty"`
+	ClientIP             string   `protobuf:"bytes,4,opt,name=clientIP,proto3" json:"clientIP,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -153,7 +271,7 @@ func (m *ConnectReq) Reset()         { *m = ConnectReq{} }
 func (m *ConnectReq) String() string { return proto.CompactTextString(m) }
 func (*ConnectReq) ProtoMessage()    {}
 func (*ConnectReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{1}
+	return fileDescriptor_2dfb3aef05fe3328, []int{2}
 }
 
 func (m *ConnectReq) XXX_Unmarshal(b []byte) error {
@@ -195,12 +313,23 @@ func (m *ConnectReq) GetToken() []byte {
 	return nil
 }
 
+func (m *ConnectReq) GetClientIP() string {
+	if m != nil {
+		return m.ClientIP
+	}
+	return ""
+}
+
 type ConnectReply struct {
 	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
 	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
 	RoomID               string   `protobuf:"bytes,3,opt,name=roomID,proto3" json:"roomID,omitempty"`
 	Accepts              []int32  `protobuf:"varint,4,rep,packed,name=accepts,proto3" json:"accepts,omitempty"`
 	Heartbeat            int64    `protobuf:"varint,5,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
+	Expire               int64    `protobuf:"varint,6,opt,name=expire,proto3" json:"expire,omitempty"`
+	Rooms                []string `protobuf:"bytes,7,rep,name=rooms,proto3" json:"rooms,omitempty"`
+	Ops                  []int32  `protobuf:"varint,8,rep,packed,name=ops,proto3" json:"ops,omitempty"`
+	Topics               []string `protobuf:"bytes,9,rep,name=topics,proto3" json:"topics,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -210,7 +339,7 @@ func (m *ConnectReply) Reset()         { *m = ConnectReply{} }
 func (m *ConnectReply) String() string { return proto.CompactTextString(m) }
 func (*ConnectReply) ProtoMessage()    {}
 func (*ConnectReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{2}
+	return fileDescriptor_2dfb3aef05fe3328, []int{3}
 }
 
 func (m *ConnectReply) XXX_Unmarshal(b []byte) error {
@@ -261,7 +390,373 @@ func (m *ConnectReply) GetAccepts() []int32 {
 
 func (m *ConnectReply) GetHeartbeat() int64 {
 	if m != nil {
-		return m.Heartbeat
+		return m.Heartbeat
+	}
+	return 0
+}
+
+func (m *ConnectReply) GetExpire() int64 {
+	if m != nil {
+		return m.Expire
+	}
+	return 0
+}
+
+func (m *ConnectReply) GetRooms() []string {
+	if m != nil {
+		return m.Rooms
+	}
+	return nil
+}
+
+func (m *ConnectReply) GetOps() []int32 {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+func (m *ConnectReply) GetTopics() []string {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+type SyncReq struct {
+	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Seq                  int64    `protobuf:"varint,4,opt,name=seq,proto3" json:"seq,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SyncReq) Reset()         { *m = SyncReq{} }
+func (m *SyncReq) String() string { return proto.CompactTextString(m) }
+func (*SyncReq) ProtoMessage()    {}
+func (*SyncReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{4}
+}
+
+func (m *SyncReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncReq.Unmarshal(m, b)
+}
+func (m *SyncReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncReq.Marshal(b, m, deterministic)
+}
+func (m *SyncReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncReq.Merge(m, src)
+}
+func (m *SyncReq) XXX_Size() int {
+	return xxx_messageInfo_SyncReq.Size(m)
+}
+func (m *SyncReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncReq proto.InternalMessageInfo
+
+func (m *SyncReq) GetMid() int64 {
+	if m != nil {
+		return m.Mid
+	}
+	return 0
+}
+
+func (m *SyncReq) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *SyncReq) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *SyncReq) GetSeq() int64 {
+	if m != nil {
+		return m.Seq
+	}
+	return 0
+}
+
+type SyncReply struct {
+	Protos               []*protocol.Proto `protobuf:"bytes,1,rep,name=protos,proto3" json:"protos,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *SyncReply) Reset()         { *m = SyncReply{} }
+func (m *SyncReply) String() string { return proto.CompactTextString(m) }
+func (*SyncReply) ProtoMessage()    {}
+func (*SyncReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{5}
+}
+
+func (m *SyncReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SyncReply.Unmarshal(m, b)
+}
+func (m *SyncReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SyncReply.Marshal(b, m, deterministic)
+}
+func (m *SyncReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SyncReply.Merge(m, src)
+}
+func (m *SyncReply) XXX_Size() int {
+	return xxx_messageInfo_SyncReply.Size(m)
+}
+func (m *SyncReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_SyncReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SyncReply proto.InternalMessageInfo
+
+func (m *SyncReply) GetProtos() []*protocol.Proto {
+	if m != nil {
+		return m.Protos
+	}
+	return nil
+}
+
+type AuthorizeReq struct {
+	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	RoomID               string   `protobuf:"bytes,4,opt,name=roomID,proto3" json:"roomID,omitempty"`
+	Ops                  []int32  `protobuf:"varint,5,rep,packed,name=ops,proto3" json:"ops,omitempty"`
+	Topics               []string `protobuf:"bytes,6,rep,name=topics,proto3" json:"topics,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthorizeReq) Reset()         { *m = AuthorizeReq{} }
+func (m *AuthorizeReq) String() string { return proto.CompactTextString(m) }
+func (*AuthorizeReq) ProtoMessage()    {}
+func (*AuthorizeReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{6}
+}
+
+func (m *AuthorizeReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuthorizeReq.Unmarshal(m, b)
+}
+func (m *AuthorizeReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuthorizeReq.Marshal(b, m, deterministic)
+}
+func (m *AuthorizeReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuthorizeReq.Merge(m, src)
+}
+func (m *AuthorizeReq) XXX_Size() int {
+	return xxx_messageInfo_AuthorizeReq.Size(m)
+}
+func (m *AuthorizeReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuthorizeReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuthorizeReq proto.InternalMessageInfo
+
+func (m *AuthorizeReq) GetMid() int64 {
+	if m != nil {
+		return m.Mid
+	}
+	return 0
+}
+
+func (m *AuthorizeReq) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *AuthorizeReq) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *AuthorizeReq) GetRoomID() string {
+	if m != nil {
+		return m.RoomID
+	}
+	return ""
+}
+
+func (m *AuthorizeReq) GetOps() []int32 {
+	if m != nil {
+		return m.Ops
+	}
+	return nil
+}
+
+func (m *AuthorizeReq) GetTopics() []string {
+	if m != nil {
+		return m.Topics
+	}
+	return nil
+}
+
+type AuthorizeReply struct {
+	Allow                bool     `protobuf:"varint,1,opt,name=allow,proto3" json:"allow,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AuthorizeReply) Reset()         { *m = AuthorizeReply{} }
+func (m *AuthorizeReply) String() string { return proto.CompactTextString(m) }
+func (*AuthorizeReply) ProtoMessage()    {}
+func (*AuthorizeReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{7}
+}
+
+func (m *AuthorizeReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuthorizeReply.Unmarshal(m, b)
+}
+func (m *AuthorizeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuthorizeReply.Marshal(b, m, deterministic)
+}
+func (m *AuthorizeReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuthorizeReply.Merge(m, src)
+}
+func (m *AuthorizeReply) XXX_Size() int {
+	return xxx_messageInfo_AuthorizeReply.Size(m)
+}
+func (m *AuthorizeReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuthorizeReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuthorizeReply proto.InternalMessageInfo
+
+func (m *AuthorizeReply) GetAllow() bool {
+	if m != nil {
+		return m.Allow
+	}
+	return false
+}
+
+type ReauthReq struct {
+	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string   `protobuf:"bytes,3,opt,name=server,proto3" json:"server,omitempty"`
+	Cookie               string   `protobuf:"bytes,4,opt,name=cookie,proto3" json:"cookie,omitempty"`
+	Token                []byte   `protobuf:"bytes,5,opt,name=token,proto3" json:"token,omitempty"`
+	ClientIP             string   `protobuf:"bytes,6,opt,name=clientIP,proto3" json:"clientIP,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReauthReq) Reset()         { *m = ReauthReq{} }
+func (m *ReauthReq) String() string { return proto.CompactTextString(m) }
+func (*ReauthReq) ProtoMessage()    {}
+func (*ReauthReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{8}
+}
+
+func (m *ReauthReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReauthReq.Unmarshal(m, b)
+}
+func (m *ReauthReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReauthReq.Marshal(b, m, deterministic)
+}
+func (m *ReauthReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReauthReq.Merge(m, src)
+}
+func (m *ReauthReq) XXX_Size() int {
+	return xxx_messageInfo_ReauthReq.Size(m)
+}
+func (m *ReauthReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReauthReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReauthReq proto.InternalMessageInfo
+
+func (m *ReauthReq) GetMid() int64 {
+	if m != nil {
+		return m.Mid
+	}
+	return 0
+}
+
+func (m *ReauthReq) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ReauthReq) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *ReauthReq) GetCookie() string {
+	if m != nil {
+		return m.Cookie
+	}
+	return ""
+}
+
+func (m *ReauthReq) GetToken() []byte {
+	if m != nil {
+		return m.Token
+	}
+	return nil
+}
+
+func (m *ReauthReq) GetClientIP() string {
+	if m != nil {
+		return m.ClientIP
+	}
+	return ""
+}
+
+type ReauthReply struct {
+	Expire               int64    `protobuf:"varint,1,opt,name=expire,proto3" json:"expire,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReauthReply) Reset()         { *m = ReauthReply{} }
+func (m *ReauthReply) String() string { return proto.CompactTextString(m) }
+func (*ReauthReply) ProtoMessage()    {}
+func (*ReauthReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{9}
+}
+
+func (m *ReauthReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReauthReply.Unmarshal(m, b)
+}
+func (m *ReauthReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReauthReply.Marshal(b, m, deterministic)
+}
+func (m *ReauthReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReauthReply.Merge(m, src)
+}
+func (m *ReauthReply) XXX_Size() int {
+	return xxx_messageInfo_ReauthReply.Size(m)
+}
+func (m *ReauthReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReauthReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReauthReply proto.InternalMessageInfo
+
+func (m *ReauthReply) GetExpire() int64 {
+	if m != nil {
+		return m.Expire
 	}
 	return 0
 }
@@ -279,7 +774,7 @@ func (m *DisconnectReq) Reset()         { *m = DisconnectReq{} }
 func (m *DisconnectReq) String() string { return proto.CompactTextString(m) }
 func (*DisconnectReq) ProtoMessage()    {}
 func (*DisconnectReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{3}
+	return fileDescriptor_2dfb3aef05fe3328, []int{10}
 }
 
 func (m *DisconnectReq) XXX_Unmarshal(b []byte) error {
@@ -332,7 +827,7 @@ func (m *DisconnectReply) Reset()         { *m = DisconnectReply{} }
 func (m *DisconnectReply) String() string { return proto.CompactTextString(m) }
 func (*DisconnectReply) ProtoMessage()    {}
 func (*DisconnectReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{4}
+	return fileDescriptor_2dfb3aef05fe3328, []int{11}
 }
 
 func (m *DisconnectReply) XXX_Unmarshal(b []byte) error {
@@ -360,6 +855,84 @@ func (m *DisconnectReply) GetHas() bool {
 	return false
 }
 
+type DisconnectsReq struct {
+	Server               string           `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Conns                map[string]int64 `protobuf:"bytes,2,rep,name=conns,proto3" json:"conns,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *DisconnectsReq) Reset()         { *m = DisconnectsReq{} }
+func (m *DisconnectsReq) String() string { return proto.CompactTextString(m) }
+func (*DisconnectsReq) ProtoMessage()    {}
+func (*DisconnectsReq) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{12}
+}
+
+func (m *DisconnectsReq) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DisconnectsReq.Unmarshal(m, b)
+}
+func (m *DisconnectsReq) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DisconnectsReq.Marshal(b, m, deterministic)
+}
+func (m *DisconnectsReq) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DisconnectsReq.Merge(m, src)
+}
+func (m *DisconnectsReq) XXX_Size() int {
+	return xxx_messageInfo_DisconnectsReq.Size(m)
+}
+func (m *DisconnectsReq) XXX_DiscardUnknown() {
+	xxx_messageInfo_DisconnectsReq.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DisconnectsReq proto.InternalMessageInfo
+
+func (m *DisconnectsReq) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *DisconnectsReq) GetConns() map[string]int64 {
+	if m != nil {
+		return m.Conns
+	}
+	return nil
+}
+
+type DisconnectsReply struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DisconnectsReply) Reset()         { *m = DisconnectsReply{} }
+func (m *DisconnectsReply) String() string { return proto.CompactTextString(m) }
+func (*DisconnectsReply) ProtoMessage()    {}
+func (*DisconnectsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{13}
+}
+
+func (m *DisconnectsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DisconnectsReply.Unmarshal(m, b)
+}
+func (m *DisconnectsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DisconnectsReply.Marshal(b, m, deterministic)
+}
+func (m *DisconnectsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DisconnectsReply.Merge(m, src)
+}
+func (m *DisconnectsReply) XXX_Size() int {
+	return xxx_messageInfo_DisconnectsReply.Size(m)
+}
+func (m *DisconnectsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_DisconnectsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DisconnectsReply proto.InternalMessageInfo
+
 type HeartbeatReq struct {
 	Mid                  int64    `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
 	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
@@ -373,7 +946,7 @@ func (m *HeartbeatReq) Reset()         { *m = HeartbeatReq{} }
 func (m *HeartbeatReq) String() string { return proto.CompactTextString(m) }
 func (*HeartbeatReq) ProtoMessage()    {}
 func (*HeartbeatReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{5}
+	return fileDescriptor_2dfb3aef05fe3328, []int{14}
 }
 
 func (m *HeartbeatReq) XXX_Unmarshal(b []byte) error {
@@ -425,7 +998,7 @@ func (m *HeartbeatReply) Reset()         { *m = HeartbeatReply{} }
 func (m *HeartbeatReply) String() string { return proto.CompactTextString(m) }
 func (*HeartbeatReply) ProtoMessage()    {}
 func (*HeartbeatReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{6}
+	return fileDescriptor_2dfb3aef05fe3328, []int{15}
 }
 
 func (m *HeartbeatReply) XXX_Unmarshal(b []byte) error {
@@ -458,7 +1031,7 @@ func (m *OnlineReq) Reset()         { *m = OnlineReq{} }
 func (m *OnlineReq) String() string { return proto.CompactTextString(m) }
 func (*OnlineReq) ProtoMessage()    {}
 func (*OnlineReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{7}
+	return fileDescriptor_2dfb3aef05fe3328, []int{16}
 }
 
 func (m *OnlineReq) XXX_Unmarshal(b []byte) error {
@@ -504,7 +1077,7 @@ func (m *OnlineReply) Reset()         { *m = OnlineReply{} }
 func (m *OnlineReply) String() string { return proto.CompactTextString(m) }
 func (*OnlineReply) ProtoMessage()    {}
 func (*OnlineReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{8}
+	return fileDescriptor_2dfb3aef05fe3328, []int{17}
 }
 
 func (m *OnlineReply) XXX_Unmarshal(b []byte) error {
@@ -535,6 +1108,9 @@ func (m *OnlineReply) GetAllRoomCount() map[string]int32 {
 type ReceiveReq struct {
 	Mid                  int64           `protobuf:"varint,1,opt,name=mid,proto3" json:"mid,omitempty"`
 	Proto                *protocol.Proto `protobuf:"bytes,2,opt,name=proto,proto3" json:"proto,omitempty"`
+	Key                  string          `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Server               string          `protobuf:"bytes,4,opt,name=server,proto3" json:"server,omitempty"`
+	Room                 string          `protobuf:"bytes,5,opt,name=room,proto3" json:"room,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
 	XXX_sizecache        int32           `json:"-"`
@@ -544,7 +1120,7 @@ func (m *ReceiveReq) Reset()         { *m = ReceiveReq{} }
 func (m *ReceiveReq) String() string { return proto.CompactTextString(m) }
 func (*ReceiveReq) ProtoMessage()    {}
 func (*ReceiveReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{9}
+	return fileDescriptor_2dfb3aef05fe3328, []int{18}
 }
 
 func (m *ReceiveReq) XXX_Unmarshal(b []byte) error {
@@ -579,7 +1155,30 @@ func (m *ReceiveReq) GetProto() *protocol.Proto {
 	return nil
 }
 
+func (m *ReceiveReq) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *ReceiveReq) GetServer() string {
+	if m != nil {
+		return m.Server
+	}
+	return ""
+}
+
+func (m *ReceiveReq) GetRoom() string {
+	if m != nil {
+		return m.Room
+	}
+	return ""
+}
+
 type ReceiveReply struct {
+	Code                 int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Msg                  string   `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -589,7 +1188,7 @@ func (m *ReceiveReply) Reset()         { *m = ReceiveReply{} }
 func (m *ReceiveReply) String() string { return proto.CompactTextString(m) }
 func (*ReceiveReply) ProtoMessage()    {}
 func (*ReceiveReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{10}
+	return fileDescriptor_2dfb3aef05fe3328, []int{19}
 }
 
 func (m *ReceiveReply) XXX_Unmarshal(b []byte) error {
@@ -610,6 +1209,20 @@ func (m *ReceiveReply) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ReceiveReply proto.InternalMessageInfo
 
+func (m *ReceiveReply) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *ReceiveReply) GetMsg() string {
+	if m != nil {
+		return m.Msg
+	}
+	return ""
+}
+
 type NodesReq struct {
 	Platform             string   `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
 	ClientIP             string   `protobuf:"bytes,2,opt,name=clientIP,proto3" json:"clientIP,omitempty"`
@@ -622,7 +1235,7 @@ func (m *NodesReq) Reset()         { *m = NodesReq{} }
 func (m *NodesReq) String() string { return proto.CompactTextString(m) }
 func (*NodesReq) ProtoMessage()    {}
 func (*NodesReq) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{11}
+	return fileDescriptor_2dfb3aef05fe3328, []int{20}
 }
 
 func (m *NodesReq) XXX_Unmarshal(b []byte) error {
@@ -675,7 +1288,7 @@ func (m *NodesReply) Reset()         { *m = NodesReply{} }
 func (m *NodesReply) String() string { return proto.CompactTextString(m) }
 func (*NodesReply) ProtoMessage()    {}
 func (*NodesReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{12}
+	return fileDescriptor_2dfb3aef05fe3328, []int{21}
 }
 
 func (m *NodesReply) XXX_Unmarshal(b []byte) error {
@@ -752,6 +1365,69 @@ func (m *NodesReply) GetHeartbeatMax() int32 {
 	return 0
 }
 
+type BackendReply struct {
+	Code                 int32    `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Msg                  string   `protobuf:"bytes,2,opt,name=msg,proto3" json:"msg,omitempty"`
+	Op                   int32    `protobuf:"varint,3,opt,name=op,proto3" json:"op,omitempty"`
+	Body                 []byte   `protobuf:"bytes,4,opt,name=body,proto3" json:"body,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BackendReply) Reset()         { *m = BackendReply{} }
+func (m *BackendReply) String() string { return proto.CompactTextString(m) }
+func (*BackendReply) ProtoMessage()    {}
+func (*BackendReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_2dfb3aef05fe3328, []int{22}
+}
+
+func (m *BackendReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackendReply.Unmarshal(m, b)
+}
+func (m *BackendReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackendReply.Marshal(b, m, deterministic)
+}
+func (m *BackendReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackendReply.Merge(m, src)
+}
+func (m *BackendReply) XXX_Size() int {
+	return xxx_messageInfo_BackendReply.Size(m)
+}
+func (m *BackendReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackendReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackendReply proto.InternalMessageInfo
+
+func (m *BackendReply) GetCode() int32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *BackendReply) GetMsg() string {
+	if m != nil {
+		return m.Msg
+	}
+	return ""
+}
+
+func (m *BackendReply) GetOp() int32 {
+	if m != nil {
+		return m.Op
+	}
+	return 0
+}
+
+func (m *BackendReply) GetBody() []byte {
+	if m != nil {
+		return m.Body
+	}
+	return nil
+}
+
 type Backoff struct {
 	MaxDelay             int32    `protobuf:"varint,1,opt,name=max_delay,json=maxDelay,proto3" json:"max_delay,omitempty"`
 	BaseDelay            int32    `protobuf:"varint,2,opt,name=base_delay,json=baseDelay,proto3" json:"base_delay,omitempty"`
@@ -766,7 +1442,7 @@ func (m *Backoff) Reset()         { *m = Backoff{} }
 func (m *Backoff) String() string { return proto.CompactTextString(m) }
 func (*Backoff) ProtoMessage()    {}
 func (*Backoff) Descriptor() ([]byte, []int) {
-	return fileDescriptor_2dfb3aef05fe3328, []int{13}
+	return fileDescriptor_2dfb3aef05fe3328, []int{23}
 }
 
 func (m *Backoff) XXX_Unmarshal(b []byte) error {
@@ -818,10 +1494,20 @@ func (m *Backoff) GetJitter() float32 {
 func init() {
 	proto.RegisterEnum("goim.logic.PushMsg_Type", PushMsg_Type_name, PushMsg_Type_value)
 	proto.RegisterType((*PushMsg)(nil), "goim.logic.PushMsg")
+	proto.RegisterType((*ReceiveMsg)(nil), "goim.logic.ReceiveMsg")
 	proto.RegisterType((*ConnectReq)(nil), "goim.logic.ConnectReq")
 	proto.RegisterType((*ConnectReply)(nil), "goim.logic.ConnectReply")
+	proto.RegisterType((*SyncReq)(nil), "goim.logic.SyncReq")
+	proto.RegisterType((*SyncReply)(nil), "goim.logic.SyncReply")
+	proto.RegisterType((*AuthorizeReq)(nil), "goim.logic.AuthorizeReq")
+	proto.RegisterType((*AuthorizeReply)(nil), "goim.logic.AuthorizeReply")
+	proto.RegisterType((*ReauthReq)(nil), "goim.logic.ReauthReq")
+	proto.RegisterType((*ReauthReply)(nil), "goim.logic.ReauthReply")
 	proto.RegisterType((*DisconnectReq)(nil), "goim.logic.DisconnectReq")
 	proto.RegisterType((*DisconnectReply)(nil), "goim.logic.DisconnectReply")
+	proto.RegisterType((*DisconnectsReq)(nil), "goim.logic.DisconnectsReq")
+	proto.RegisterMapType((map[string]int64)(nil), "goim.logic.DisconnectsReq.ConnsEntry")
+	proto.RegisterType((*DisconnectsReply)(nil), "goim.logic.DisconnectsReply")
 	proto.RegisterType((*HeartbeatReq)(nil), "goim.logic.HeartbeatReq")
 	proto.RegisterType((*HeartbeatReply)(nil), "goim.logic.HeartbeatReply")
 	proto.RegisterType((*OnlineReq)(nil), "goim.logic.OnlineReq")
@@ -832,69 +1518,97 @@ func init() {
 	proto.RegisterType((*ReceiveReply)(nil), "goim.logic.ReceiveReply")
 	proto.RegisterType((*NodesReq)(nil), "goim.logic.NodesReq")
 	proto.RegisterType((*NodesReply)(nil), "goim.logic.NodesReply")
+	proto.RegisterType((*BackendReply)(nil), "goim.logic.BackendReply")
 	proto.RegisterType((*Backoff)(nil), "goim.logic.Backoff")
 }
 
 func init() { proto.RegisterFile("logic/logic.proto", fileDescriptor_2dfb3aef05fe3328) }
 
 var fileDescriptor_2dfb3aef05fe3328 = []byte{
-	// 893 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x55, 0x4f, 0x6f, 0xe3, 0x44,
-	0x14, 0xc7, 0x71, 0x9c, 0xc4, 0x2f, 0x69, 0xc9, 0x0e, 0x25, 0xeb, 0x7a, 0x41, 0x8a, 0xbc, 0x1c,
-	0x52, 0x60, 0x13, 0x29, 0x68, 0x25, 0xc4, 0x82, 0x50, 0xd3, 0x20, 0xed, 0x2e, 0x84, 0x46, 0xb3,
-	0xe5, 0xc2, 0xa5, 0x9a, 0x38, 0xd3, 0xd4, 0xc4, 0xf6, 0x18, 0x7b, 0xd2, 0xd4, 0x37, 0xc4, 0xf7,
-	0xe0, 0xc8, 0x77, 0xe3, 0x3b, 0x70, 0x41, 0xf3, 0x27, 0xb6, 0xa3, 0x4d, 0x57, 0x20, 0x2e, 0xd6,
-	0xfb, 0x37, 0xbf, 0xf9, 0xbd, 0xf7, 0xe6, 0x3d, 0xc3, 0xa3, 0x90, 0xad, 0x02, 0x7f, 0x24, 0xbf,
-	0xc3, 0x24, 0x65, 0x9c, 0x21, 0x58, 0xb1, 0x20, 0x1a, 0x4a, 0x8b, 0xfb, 0x7c, 0x15, 0xf0, 0xdb,
-	0xcd, 0x62, 0xe8, 0xb3, 0x68, 0x74, 0x45, 0xd3, 0x34, 0x7f, 0x36, 0x23, 0x6c, 0x24, 0x02, 0x46,
-	0x24, 0x09, 0x46, 0xf2, 0x80, 0xcf, 0xc2, 0x42, 0x50, 0x10, 0xde, 0x5f, 0x06, 0x34, 0xe7, 0x9b,
-	0xec, 0x76, 0x96, 0xad, 0xd0, 0xe7, 0x50, 0xe7, 0x79, 0x42, 0x1d, 0xa3, 0x6f, 0x0c, 0x8e, 0xc7,
-	0xce, 0xb0, 0x44, 0x1f, 0xea, 0x90, 0xe1, 0x55, 0x9e, 0x50, 0x2c, 0xa3, 0xd0, 0x47, 0x60, 0xb3,
-	0x84, 0xa6, 0x84, 0x07, 0x2c, 0x76, 0x6a, 0x7d, 0x63, 0x60, 0xe1, 0xd2, 0x80, 0x4e, 0xc0, 0xca,
-	0x12, 0x4a, 0x97, 0x8e, 0x29, 0x3d, 0x4a, 0x41, 0x3d, 0x68, 0x64, 0x34, 0xbd, 0xa3, 0xa9, 0x53,
-	0xef, 0x1b, 0x03, 0x1b, 0x6b, 0x0d, 0x21, 0xa8, 0xa7, 0x8c, 0x45, 0x8e, 0x25, 0xad, 0x52, 0x16,
-	0xb6, 0x35, 0xcd, 0x33, 0xa7, 0xd1, 0x37, 0x85, 0x4d, 0xc8, 0xa8, 0x0b, 0x66, 0x94, 0xad, 0x9c,
-	0x66, 0xdf, 0x18, 0x74, 0xb0, 0x10, 0xbd, 0x33, 0xa8, 0x0b, 0x4e, 0xa8, 0x05, 0xf5, 0xf9, 0x4f,
-	0x6f, 0x5e, 0x76, 0xdf, 0x13, 0x12, 0xbe, 0xbc, 0x9c, 0x75, 0x0d, 0x74, 0x04, 0xf6, 0x04, 0x5f,
-	0x9e, 0x4f, 0x2f, 0xce, 0xdf, 0x5c, 0x75, 0x6b, 0x1e, 0x06, 0xb8, 0x60, 0x71, 0x4c, 0x7d, 0x8e,
-	0xe9, 0xaf, 0x15, 0x2a, 0xc6, 0x1e, 0x95, 0x1e, 0x34, 0x7c, 0xc6, 0xd6, 0x01, 0x95, 0x39, 0xd9,
-	0x58, 0x6b, 0x22, 0x21, 0xce, 0xd6, 0x34, 0x96, 0x09, 0x75, 0xb0, 0x52, 0xbc, 0xdf, 0x0d, 0xe8,
-	0x14, 0xa0, 0x49, 0x98, 0x4b, 0x86, 0xc1, 0x52, 0x62, 0x9a, 0x58, 0x88, 0xc2, 0xb2, 0xa6, 0xb9,
-	0x46, 0x13, 0xa2, 0xb8, 0x42, 0x64, 0xf8, 0x6a, 0x2a, 0xb1, 0x6c, 0xac, 0x35, 0xe4, 0x40, 0x93,
-	0xf8, 0x3e, 0x4d, 0x78, 0xe6, 0xd4, 0xfb, 0xe6, 0xc0, 0xc2, 0x3b, 0x55, 0xd4, 0xfa, 0x96, 0x92,
-	0x94, 0x2f, 0x28, 0xe1, 0xb2, 0x48, 0x26, 0x2e, 0x0d, 0xde, 0xf7, 0x70, 0x34, 0x0d, 0x32, 0xbf,
-	0xcc, 0xed, 0x5f, 0x92, 0xd0, 0xf9, 0x9b, 0xd5, 0xfc, 0xbd, 0xa7, 0xf0, 0x7e, 0x15, 0x4c, 0xe7,
-	0x74, 0x4b, 0x32, 0x09, 0xd7, 0xc2, 0x42, 0xf4, 0x5e, 0x43, 0xe7, 0xe5, 0xee, 0xfa, 0xff, 0x7b,
-	0x61, 0x17, 0x8e, 0x2b, 0x58, 0x49, 0x98, 0x7b, 0x7f, 0x1a, 0x60, 0x5f, 0xc6, 0x61, 0x10, 0xd3,
-	0x77, 0x35, 0x6a, 0x02, 0xb6, 0xa8, 0xdb, 0x05, 0xdb, 0xc4, 0xdc, 0xa9, 0xf5, 0xcd, 0x41, 0x7b,
-	0xfc, 0x49, 0xf5, 0xc9, 0x16, 0x08, 0x43, 0xbc, 0x0b, 0xfb, 0x2e, 0xe6, 0x69, 0x8e, 0xcb, 0x63,
-	0xee, 0xd7, 0x70, 0xbc, 0xef, 0xdc, 0xf1, 0x36, 0x4a, 0xde, 0x27, 0x60, 0xdd, 0x91, 0x70, 0x43,
-	0xf5, 0x1b, 0x57, 0xca, 0x57, 0xb5, 0x2f, 0x0d, 0xef, 0x0f, 0x03, 0xda, 0xbb, 0x5b, 0x44, 0x9d,
-	0x66, 0xd0, 0x21, 0x61, 0x58, 0x00, 0x3a, 0x86, 0x24, 0x75, 0x76, 0x88, 0x54, 0x12, 0xe6, 0xc3,
-	0xf3, 0x4a, 0xac, 0x62, 0xb6, 0x77, 0xdc, 0xfd, 0x16, 0x1e, 0xbd, 0x15, 0xf2, 0x9f, 0xf8, 0xbd,
-	0x06, 0xc0, 0xd4, 0xa7, 0xc1, 0x1d, 0x3d, 0xdc, 0xa3, 0x4f, 0xc1, 0x92, 0x4b, 0x40, 0x9e, 0x6c,
-	0x8f, 0x4f, 0x14, 0xd1, 0x62, 0x41, 0xcc, 0x85, 0x80, 0x55, 0x88, 0x77, 0x0c, 0x9d, 0x02, 0x4b,
-	0xf4, 0x68, 0x02, 0xad, 0x1f, 0xd9, 0x92, 0x66, 0x02, 0xd9, 0x85, 0x56, 0x12, 0x12, 0x7e, 0xc3,
-	0xd2, 0x48, 0x13, 0x2b, 0x74, 0xe1, 0xf3, 0xc3, 0x80, 0xc6, 0xfc, 0xd5, 0x5c, 0x3f, 0x86, 0x42,
-	0xf7, 0xfe, 0x36, 0x00, 0x34, 0x88, 0x28, 0x5f, 0x0f, 0x1a, 0x4b, 0x16, 0x91, 0x20, 0xde, 0x35,
-	0x5a, 0x69, 0xe8, 0x14, 0x5a, 0xdc, 0x4f, 0xae, 0x13, 0x96, 0x72, 0x9d, 0x63, 0x93, 0xfb, 0xc9,
-	0x9c, 0xa5, 0x1c, 0x3d, 0x86, 0xe6, 0x36, 0x53, 0x1e, 0xb5, 0x67, 0x1a, 0xdb, 0x4c, 0x3a, 0x4e,
-	0xa1, 0xb5, 0xcd, 0xb4, 0xa7, 0xae, 0xce, 0x6c, 0x33, 0xe5, 0x7a, 0x6b, 0x96, 0xac, 0xca, 0x2c,
-	0x89, 0x6a, 0xc6, 0x82, 0x92, 0x5e, 0x3b, 0x4a, 0x41, 0xcf, 0xa0, 0xb9, 0x20, 0xfe, 0x9a, 0xdd,
-	0xdc, 0xc8, 0xdd, 0xd3, 0x1e, 0x7f, 0x50, 0x6d, 0xea, 0x44, 0xb9, 0xf0, 0x2e, 0x06, 0x3d, 0x85,
-	0xa3, 0x02, 0xf1, 0x3a, 0x22, 0xf7, 0x4e, 0x4b, 0x5e, 0xd3, 0x29, 0x8c, 0x33, 0x72, 0xef, 0x6d,
-	0xa0, 0xa9, 0x0f, 0xa2, 0x27, 0x60, 0x47, 0xe4, 0xfe, 0x7a, 0x49, 0x43, 0xa2, 0x5a, 0x6b, 0xe1,
-	0x56, 0x44, 0xee, 0xa7, 0x42, 0x47, 0x1f, 0x03, 0x2c, 0x48, 0x46, 0xb5, 0x57, 0x2f, 0x5a, 0x61,
-	0x51, 0xee, 0x1e, 0x34, 0x6e, 0x88, 0xcf, 0x99, 0x1a, 0xab, 0x1a, 0xd6, 0x9a, 0xb0, 0xff, 0x12,
-	0x70, 0xae, 0x57, 0x6d, 0x0d, 0x6b, 0x6d, 0xfc, 0x9b, 0x09, 0xd6, 0x0f, 0x82, 0x36, 0x7a, 0x01,
-	0x4d, 0xbd, 0xba, 0x50, 0xaf, 0x9a, 0x4e, 0xb9, 0x24, 0x5d, 0xe7, 0xa0, 0x5d, 0x34, 0x6b, 0x0a,
-	0x50, 0xae, 0x09, 0x74, 0x5a, 0x8d, 0xdb, 0xdb, 0x45, 0xee, 0x93, 0x87, 0x5c, 0x02, 0xe5, 0x1c,
-	0xec, 0x62, 0xf6, 0xd1, 0xde, 0x65, 0xd5, 0xf5, 0xe2, 0xba, 0x0f, 0x78, 0x04, 0xc4, 0x37, 0xd0,
-	0xc6, 0x34, 0xa6, 0x5b, 0x35, 0x59, 0xe8, 0xc3, 0x83, 0x2b, 0xc0, 0x7d, 0xfc, 0xc0, 0x10, 0x8a,
-	0x22, 0xe8, 0x77, 0xbd, 0x5f, 0x84, 0x72, 0x70, 0xf6, 0x8b, 0x50, 0x1d, 0x02, 0xf4, 0x1c, 0x2c,
-	0xf9, 0x7e, 0xd1, 0x49, 0x35, 0x64, 0x37, 0x17, 0x6e, 0xef, 0x80, 0x35, 0x09, 0xf3, 0xc9, 0x67,
-	0x3f, 0x9f, 0xbd, 0xfb, 0x67, 0x2d, 0x4f, 0xbc, 0x90, 0xdf, 0x45, 0x43, 0xce, 0xdf, 0x17, 0xff,
-	0x04, 0x00, 0x00, 0xff, 0xff, 0x6f, 0x34, 0x54, 0x90, 0xff, 0x07, 0x00, 0x00,
+	// 1328 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0xdd, 0x72, 0xdb, 0x44,
+	0x14, 0x46, 0x96, 0x64, 0x5b, 0x27, 0x6e, 0x70, 0x97, 0x34, 0x55, 0xd5, 0x32, 0xe3, 0x51, 0x29,
+	0x93, 0x42, 0xeb, 0xcc, 0x04, 0x3a, 0x53, 0x08, 0x3f, 0x93, 0x1f, 0xa0, 0xa1, 0x84, 0x64, 0xb6,
+	0x61, 0x60, 0xb8, 0xe9, 0x28, 0xf2, 0xc6, 0x11, 0x91, 0xb5, 0xaa, 0x56, 0x6e, 0x62, 0x9e, 0x80,
+	0x0b, 0x6e, 0x78, 0x00, 0x86, 0x2b, 0x78, 0x1b, 0x5e, 0x80, 0x47, 0x81, 0x1b, 0xe6, 0xec, 0xae,
+	0xf5, 0xd3, 0xda, 0x09, 0x9d, 0x70, 0xe3, 0x39, 0xff, 0x3a, 0xfb, 0x9d, 0xb3, 0x67, 0x8f, 0xe1,
+	0x6a, 0xcc, 0x87, 0x51, 0xb8, 0x2a, 0x7f, 0xfb, 0x69, 0xc6, 0x73, 0x4e, 0x60, 0xc8, 0xa3, 0x51,
+	0x5f, 0x4a, 0xbc, 0x07, 0xc3, 0x28, 0x3f, 0x1e, 0x1f, 0xf6, 0x43, 0x3e, 0x5a, 0x3d, 0x60, 0x59,
+	0x36, 0xb9, 0xbf, 0x1b, 0xf0, 0x55, 0x34, 0x58, 0x0d, 0xd2, 0x68, 0x55, 0x3a, 0x84, 0x3c, 0x2e,
+	0x08, 0x15, 0xc2, 0xff, 0xb3, 0x01, 0xad, 0xfd, 0xb1, 0x38, 0xde, 0x15, 0x43, 0x72, 0x0f, 0xac,
+	0x7c, 0x92, 0x32, 0xd7, 0xe8, 0x19, 0x2b, 0x8b, 0x6b, 0x6e, 0xbf, 0x8c, 0xde, 0xd7, 0x26, 0xfd,
+	0x83, 0x49, 0xca, 0xa8, 0xb4, 0x22, 0xb7, 0xc0, 0xe1, 0x29, 0xcb, 0x82, 0x3c, 0xe2, 0x89, 0xdb,
+	0xe8, 0x19, 0x2b, 0x36, 0x2d, 0x05, 0x64, 0x09, 0x6c, 0x91, 0x32, 0x36, 0x70, 0x4d, 0xa9, 0x51,
+	0x0c, 0x59, 0x86, 0xa6, 0x60, 0xd9, 0x73, 0x96, 0xb9, 0x56, 0xcf, 0x58, 0x71, 0xa8, 0xe6, 0x08,
+	0x01, 0x2b, 0xe3, 0x7c, 0xe4, 0xda, 0x52, 0x2a, 0x69, 0x94, 0x9d, 0xb0, 0x89, 0x70, 0x9b, 0x3d,
+	0x13, 0x65, 0x48, 0x93, 0x2e, 0x98, 0x23, 0x31, 0x74, 0x5b, 0x3d, 0x63, 0xa5, 0x43, 0x91, 0x24,
+	0x8b, 0xd0, 0x88, 0x52, 0xb7, 0x2d, 0xfd, 0x1a, 0x51, 0x8a, 0xdf, 0xcd, 0x79, 0x1a, 0x85, 0xae,
+	0x23, 0x45, 0x8a, 0xc1, 0x58, 0x82, 0x3d, 0x13, 0x2e, 0xf4, 0xcc, 0x15, 0x9b, 0x4a, 0x5a, 0x5a,
+	0x66, 0x41, 0xc8, 0xdc, 0x05, 0x6d, 0x89, 0x8c, 0xff, 0x09, 0x58, 0x78, 0x46, 0xd2, 0x06, 0x6b,
+	0xff, 0x9b, 0x27, 0x8f, 0xba, 0xaf, 0x21, 0x45, 0xf7, 0xf6, 0x76, 0xbb, 0x06, 0xb9, 0x02, 0xce,
+	0x26, 0xdd, 0xdb, 0xd8, 0xde, 0xda, 0x78, 0x72, 0xd0, 0x6d, 0xa0, 0xe2, 0xf1, 0xce, 0xd6, 0xe3,
+	0xae, 0x49, 0x1c, 0xb0, 0x0f, 0xf6, 0xf6, 0x77, 0xb6, 0xba, 0x96, 0xff, 0x87, 0x01, 0x40, 0x59,
+	0xc8, 0xa2, 0xe7, 0x0c, 0x21, 0xc5, 0x84, 0xa3, 0x81, 0x44, 0xd4, 0xa4, 0x48, 0xa2, 0xe4, 0x84,
+	0x4d, 0x24, 0x60, 0x0e, 0x45, 0xb2, 0x02, 0x8a, 0x39, 0x13, 0x14, 0xab, 0x02, 0xca, 0x2d, 0x70,
+	0xf2, 0x68, 0xc4, 0x44, 0x1e, 0x8c, 0x52, 0x89, 0x96, 0x49, 0x4b, 0x01, 0x79, 0x07, 0x6c, 0x59,
+	0x55, 0xb7, 0xd9, 0x33, 0x56, 0x16, 0xd6, 0x96, 0x54, 0x05, 0x8b, 0x8a, 0xef, 0x23, 0x41, 0x95,
+	0x89, 0x9f, 0x00, 0x6c, 0xf1, 0x24, 0x61, 0x61, 0x4e, 0xd9, 0xb3, 0x4a, 0x0e, 0x46, 0x2d, 0x87,
+	0x65, 0x68, 0x86, 0x9c, 0x9f, 0x44, 0x4c, 0x27, 0xac, 0x39, 0x05, 0xf3, 0x09, 0x4b, 0x64, 0xca,
+	0x1d, 0xaa, 0x18, 0xe2, 0x41, 0x3b, 0x8c, 0x23, 0x96, 0xe4, 0x3b, 0xfb, 0x3a, 0xeb, 0x82, 0xf7,
+	0xff, 0x32, 0xa0, 0x53, 0x7c, 0x30, 0x8d, 0x27, 0xff, 0x15, 0x1a, 0x3c, 0xf6, 0xce, 0xf6, 0x14,
+	0x1a, 0xc5, 0x11, 0x17, 0x5a, 0x41, 0x18, 0xb2, 0x34, 0x17, 0xae, 0x25, 0x4b, 0x3a, 0x65, 0x11,
+	0xa0, 0x63, 0x16, 0x64, 0xf9, 0x21, 0x0b, 0xf2, 0x29, 0x40, 0x85, 0x00, 0xe3, 0xb1, 0xb3, 0x34,
+	0xca, 0x98, 0x44, 0xc8, 0xa4, 0x9a, 0xc3, 0xe3, 0x60, 0x64, 0xe1, 0xb6, 0x64, 0xb3, 0x29, 0x06,
+	0xf3, 0xe1, 0xa9, 0x70, 0xdb, 0xf2, 0x0b, 0x48, 0xa2, 0xbf, 0x6c, 0x28, 0xe1, 0x3a, 0xd2, 0x50,
+	0x73, 0xfe, 0xb7, 0xd0, 0x7a, 0x32, 0x49, 0x42, 0x44, 0xf2, 0x32, 0x15, 0xef, 0x82, 0x29, 0xd8,
+	0x33, 0x09, 0x9d, 0x49, 0x91, 0xf4, 0x3f, 0x00, 0x47, 0x05, 0x46, 0xc4, 0xee, 0x41, 0x53, 0xd6,
+	0x4e, 0xb8, 0x46, 0xcf, 0x9c, 0x5b, 0x5f, 0x6d, 0xe3, 0xff, 0x6c, 0x40, 0x67, 0x63, 0x9c, 0x1f,
+	0xf3, 0x2c, 0xfa, 0x91, 0x5d, 0x36, 0xb3, 0xb2, 0x10, 0x56, 0xad, 0x10, 0x1a, 0x22, 0x7b, 0x16,
+	0x44, 0xcd, 0x1a, 0x44, 0x6f, 0xc3, 0x62, 0x25, 0x1b, 0x3c, 0xce, 0x12, 0xd8, 0x41, 0x1c, 0xf3,
+	0x53, 0x99, 0x51, 0x9b, 0x2a, 0xc6, 0xff, 0xc5, 0x00, 0x87, 0xb2, 0x60, 0x9c, 0x1f, 0xff, 0x0f,
+	0x39, 0xeb, 0xde, 0xb5, 0x66, 0xf7, 0xae, 0x3d, 0xaf, 0x77, 0x9b, 0x2f, 0xf4, 0xee, 0x1d, 0x58,
+	0x98, 0xa6, 0x84, 0x89, 0x97, 0x5d, 0x64, 0x54, 0xbb, 0xc8, 0x7f, 0x0c, 0x57, 0xb6, 0x23, 0x11,
+	0x96, 0xb7, 0xea, 0x12, 0xd9, 0xfb, 0xb7, 0xe1, 0xf5, 0x6a, 0x30, 0x7d, 0x63, 0x8e, 0x03, 0xa1,
+	0xe1, 0x42, 0xd2, 0xff, 0xcd, 0x80, 0xc5, 0xd2, 0x4a, 0x9c, 0x77, 0x93, 0xd7, 0xc1, 0x46, 0x33,
+	0xe1, 0x36, 0x64, 0xef, 0xdc, 0xa9, 0x4e, 0xf7, 0x7a, 0x88, 0x3e, 0x5e, 0x53, 0xf1, 0x59, 0x92,
+	0x67, 0x13, 0xaa, 0x7c, 0xbc, 0x87, 0x00, 0xa5, 0x70, 0x7a, 0x08, 0xa3, 0x3c, 0xc4, 0x12, 0xd8,
+	0xcf, 0x83, 0x78, 0xac, 0xa6, 0x84, 0x49, 0x15, 0xf3, 0x61, 0xe3, 0xa1, 0xe1, 0x13, 0xe8, 0xd6,
+	0xa2, 0xa7, 0xf1, 0xc4, 0xff, 0x12, 0x3a, 0x8f, 0xa6, 0x57, 0xf2, 0xb2, 0x30, 0x75, 0x61, 0xb1,
+	0x12, 0x0b, 0xa3, 0xff, 0x6e, 0x80, 0xb3, 0x97, 0xc4, 0x51, 0xc2, 0xce, 0x83, 0x63, 0x13, 0x1c,
+	0x6c, 0xe1, 0x2d, 0x3e, 0x4e, 0x72, 0x0d, 0xc9, 0x5b, 0x55, 0x48, 0x8a, 0x08, 0x7d, 0x3a, 0x35,
+	0x53, 0x88, 0x94, 0x6e, 0xde, 0x47, 0xb0, 0x58, 0x57, 0x5e, 0x84, 0x8c, 0x5d, 0x45, 0xe6, 0x57,
+	0x03, 0x16, 0xa6, 0x5f, 0xc1, 0xea, 0xee, 0x42, 0x27, 0x88, 0xe3, 0x22, 0xa0, 0xbe, 0xe3, 0x77,
+	0x67, 0x25, 0x95, 0xc6, 0x93, 0xfe, 0x46, 0x1c, 0xd7, 0x3f, 0x4e, 0x6b, 0xee, 0xde, 0xa7, 0x70,
+	0xf5, 0x25, 0x93, 0x57, 0xca, 0xef, 0xa7, 0xf2, 0x25, 0x9b, 0x5d, 0xa4, 0xe2, 0xb5, 0x69, 0x5c,
+	0xf8, 0xda, 0x4c, 0x3f, 0x6c, 0xce, 0x2a, 0xe8, 0x85, 0xab, 0x80, 0xff, 0x3e, 0x74, 0x8a, 0x4c,
+	0x10, 0x2a, 0x02, 0x56, 0xc8, 0x07, 0xea, 0xfa, 0xd9, 0x54, 0xd2, 0xd3, 0xd5, 0x40, 0xb7, 0xcc,
+	0x48, 0x0c, 0xfd, 0x4d, 0x68, 0x7f, 0xcd, 0x07, 0x4c, 0xde, 0x0a, 0x0f, 0xda, 0x69, 0x1c, 0xe4,
+	0x47, 0x3c, 0x1b, 0xe9, 0xd3, 0x17, 0x7c, 0xed, 0xe6, 0x37, 0x5e, 0xb8, 0xf9, 0xff, 0x18, 0x00,
+	0x3a, 0x88, 0xbe, 0xf9, 0x03, 0x3e, 0x0a, 0xa2, 0x64, 0xda, 0x4d, 0x8a, 0x23, 0x37, 0xa0, 0x9d,
+	0x87, 0xe9, 0xd3, 0x94, 0x67, 0xb9, 0x06, 0xb2, 0x95, 0x87, 0xe9, 0x3e, 0xcf, 0x72, 0x72, 0x1d,
+	0x5a, 0xa7, 0x42, 0x69, 0xd4, 0x2a, 0xd4, 0x3c, 0x15, 0x52, 0x71, 0x03, 0xda, 0xa7, 0x42, 0x6b,
+	0x2c, 0xe5, 0x73, 0x2a, 0x94, 0xea, 0xa5, 0x47, 0xcc, 0xae, 0x3e, 0x62, 0x4b, 0x60, 0x27, 0x98,
+	0x92, 0x1e, 0xb0, 0x8a, 0x21, 0xf7, 0xa1, 0x75, 0x18, 0x84, 0x27, 0xfc, 0xe8, 0x48, 0xae, 0x47,
+	0x0b, 0x6b, 0x6f, 0x54, 0x3b, 0x67, 0x53, 0xa9, 0xe8, 0xd4, 0x86, 0xdc, 0x86, 0x2b, 0x45, 0xc4,
+	0xa7, 0xa3, 0xe0, 0x4c, 0xae, 0x50, 0x36, 0xed, 0x14, 0xc2, 0xdd, 0xe0, 0xcc, 0xff, 0x0e, 0x3a,
+	0xe8, 0xc8, 0x92, 0xc1, 0x2b, 0xe0, 0x8e, 0x2b, 0x19, 0x4f, 0xf5, 0x61, 0x1b, 0x3c, 0x45, 0xaf,
+	0x43, 0x3e, 0x98, 0xc8, 0x43, 0x76, 0xa8, 0xa4, 0xfd, 0x31, 0xb4, 0x74, 0x4a, 0xe4, 0x26, 0x38,
+	0xa3, 0xe0, 0xec, 0xe9, 0x80, 0xc5, 0xc1, 0x44, 0x47, 0x6e, 0x8f, 0x82, 0xb3, 0x6d, 0xe4, 0xc9,
+	0x9b, 0x00, 0x87, 0x81, 0x60, 0x5a, 0xab, 0xb7, 0x4c, 0x94, 0x28, 0xf5, 0x32, 0x34, 0x8f, 0x82,
+	0x30, 0xe7, 0x6a, 0x2a, 0x34, 0xa8, 0xe6, 0x50, 0xfe, 0x43, 0x94, 0xe7, 0xba, 0xb9, 0x1a, 0x54,
+	0x73, 0x6b, 0x7f, 0x5b, 0x60, 0x7f, 0x85, 0x80, 0x90, 0x75, 0x68, 0xe9, 0x6d, 0x84, 0x2c, 0x57,
+	0x81, 0x2a, 0x77, 0x22, 0xcf, 0x9d, 0x29, 0x47, 0x1c, 0xb6, 0x01, 0xca, 0xa1, 0x46, 0x6e, 0xcc,
+	0x1e, 0xa5, 0x18, 0xe2, 0xe6, 0x3c, 0x15, 0x46, 0x79, 0x08, 0x4d, 0xf5, 0xaa, 0x90, 0x6b, 0x55,
+	0xb3, 0xe2, 0xf1, 0xf3, 0xae, 0xcf, 0x12, 0xa3, 0xe7, 0x1a, 0x58, 0xb8, 0x15, 0x90, 0x5a, 0x89,
+	0xf5, 0x02, 0xe2, 0x5d, 0x7b, 0x59, 0x88, 0x3e, 0x1b, 0xe0, 0x14, 0xef, 0x2f, 0xa9, 0x1d, 0xad,
+	0xba, 0x24, 0x78, 0xde, 0x1c, 0x0d, 0x86, 0xf8, 0x02, 0x16, 0x2a, 0xb3, 0x9c, 0x78, 0xf3, 0x9f,
+	0x10, 0xef, 0xd6, 0x5c, 0x9d, 0xce, 0xa5, 0x18, 0xda, 0xf5, 0x5c, 0xaa, 0xef, 0x82, 0xe7, 0xcd,
+	0xd1, 0x60, 0x88, 0x8f, 0xf1, 0x49, 0x4e, 0xd8, 0xa9, 0x1a, 0x89, 0x75, 0x04, 0x8b, 0xd9, 0xed,
+	0x5d, 0x9f, 0x25, 0x46, 0xf7, 0x75, 0x68, 0xe9, 0x89, 0x52, 0x2f, 0x7f, 0x39, 0xf0, 0x3c, 0x77,
+	0xa6, 0x1c, 0x9d, 0x1f, 0x80, 0x2d, 0x67, 0x02, 0x59, 0xaa, 0x9a, 0x4c, 0x67, 0x8d, 0xb7, 0x3c,
+	0x43, 0x9a, 0xc6, 0x93, 0xb5, 0xcf, 0x55, 0xcf, 0xb3, 0x64, 0x70, 0xd1, 0xe7, 0x77, 0xc5, 0xb0,
+	0xfe, 0xf9, 0xea, 0x2d, 0xdc, 0x7c, 0xf7, 0xfb, 0xbb, 0xe7, 0xff, 0xd7, 0x93, 0x3e, 0xeb, 0xf2,
+	0xf7, 0x50, 0x6d, 0x83, 0xef, 0xfd, 0x3b, 0x00, 0x75, 0x3d, 0xfd, 0x16, 0x3e, 0x0e, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -913,6 +1627,14 @@ type LogicClient interface {
 	Connect(ctx context.Context, in *ConnectReq, opts ...grpc.CallOption) (*ConnectReply, error)
 	// Disconnect
 	Disconnect(ctx context.Context, in *DisconnectReq, opts ...grpc.CallOption) (*DisconnectReply, error)
+	// Reauth re-authenticate a conn to extend the session
+	Reauth(ctx context.Context, in *ReauthReq, opts ...grpc.CallOption) (*ReauthReply, error)
+	// Sync get the inbox messages after the seq
+	Sync(ctx context.Context, in *SyncReq, opts ...grpc.CallOption) (*SyncReply, error)
+	// Authorize check if a conn may enter the room or subscribe the ops
+	Authorize(ctx context.Context, in *AuthorizeReq, opts ...grpc.CallOption) (*AuthorizeReply, error)
+	// Disconnects disconnect a batch of conns
+	Disconnects(ctx context.Context, in *DisconnectsReq, opts ...grpc.CallOption) (*DisconnectsReply, error)
 	// Heartbeat
 	Heartbeat(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatReply, error)
 	// RenewOnline
@@ -949,6 +1671,42 @@ func (c *logicClient) Disconnect(ctx context.Context, in *DisconnectReq, opts ..
 	return out, nil
 }
 
+func (c *logicClient) Reauth(ctx context.Context, in *ReauthReq, opts ...grpc.CallOption) (*ReauthReply, error) {
+	out := new(ReauthReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Reauth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Sync(ctx context.Context, in *SyncReq, opts ...grpc.CallOption) (*SyncReply, error) {
+	out := new(SyncReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Sync", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Authorize(ctx context.Context, in *AuthorizeReq, opts ...grpc.CallOption) (*AuthorizeReply, error) {
+	out := new(AuthorizeReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Authorize", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *logicClient) Disconnects(ctx context.Context, in *DisconnectsReq, opts ...grpc.CallOption) (*DisconnectsReply, error) {
+	out := new(DisconnectsReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Disconnects", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *logicClient) Heartbeat(ctx context.Context, in *HeartbeatReq, opts ...grpc.CallOption) (*HeartbeatReply, error) {
 	out := new(HeartbeatReply)
 	err := c.cc.Invoke(ctx, "/goim.logic.Logic/Heartbeat", in, out, opts...)
@@ -991,6 +1749,14 @@ type LogicServer interface {
 	Connect(context.Context, *ConnectReq) (*ConnectReply, error)
 	// Disconnect
 	Disconnect(context.Context, *DisconnectReq) (*DisconnectReply, error)
+	// Reauth re-authenticate a conn to extend the session
+	Reauth(context.Context, *ReauthReq) (*ReauthReply, error)
+	// Sync get the inbox messages after the seq
+	Sync(context.Context, *SyncReq) (*SyncReply, error)
+	// Authorize check if a conn may enter the room or subscribe the ops
+	Authorize(context.Context, *AuthorizeReq) (*AuthorizeReply, error)
+	// Disconnects disconnect a batch of conns
+	Disconnects(context.Context, *DisconnectsReq) (*DisconnectsReply, error)
 	// Heartbeat
 	Heartbeat(context.Context, *HeartbeatReq) (*HeartbeatReply, error)
 	// RenewOnline
@@ -1011,6 +1777,18 @@ func (*UnimplementedLogicServer) Connect(ctx context.Context, req *ConnectReq) (
 func (*UnimplementedLogicServer) Disconnect(ctx context.Context, req *DisconnectReq) (*DisconnectReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Disconnect not implemented")
 }
+func (*UnimplementedLogicServer) Reauth(ctx context.Context, req *ReauthReq) (*ReauthReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Reauth not implemented")
+}
+func (*UnimplementedLogicServer) Sync(ctx context.Context, req *SyncReq) (*SyncReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Sync not implemented")
+}
+func (*UnimplementedLogicServer) Authorize(ctx context.Context, req *AuthorizeReq) (*AuthorizeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Authorize not implemented")
+}
+func (*UnimplementedLogicServer) Disconnects(ctx context.Context, req *DisconnectsReq) (*DisconnectsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Disconnects not implemented")
+}
 func (*UnimplementedLogicServer) Heartbeat(ctx context.Context, req *HeartbeatReq) (*HeartbeatReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
 }
@@ -1064,6 +1842,78 @@ func _Logic_Disconnect_Handler(srv interface{}, ctx context.Context, dec func(in
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Logic_Reauth_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReauthReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).Reauth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/Reauth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).Reauth(ctx, req.(*ReauthReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_Sync_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SyncReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).Sync(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/Sync",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).Sync(ctx, req.(*SyncReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_Authorize_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AuthorizeReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).Authorize(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/Authorize",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).Authorize(ctx, req.(*AuthorizeReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Logic_Disconnects_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisconnectsReq)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LogicServer).Disconnects(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Logic/Disconnects",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LogicServer).Disconnects(ctx, req.(*DisconnectsReq))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Logic_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(HeartbeatReq)
 	if err := dec(in); err != nil {
@@ -1148,6 +1998,22 @@ var _Logic_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Disconnect",
 			Handler:    _Logic_Disconnect_Handler,
 		},
+		{
+			MethodName: "Reauth",
+			Handler:    _Logic_Reauth_Handler,
+		},
+		{
+			MethodName: "Sync",
+			Handler:    _Logic_Sync_Handler,
+		},
+		{
+			MethodName: "Authorize",
+			Handler:    _Logic_Authorize_Handler,
+		},
+		{
+			MethodName: "Disconnects",
+			Handler:    _Logic_Disconnects_Handler,
+		},
 		{
 			MethodName: "Heartbeat",
 			Handler:    _Logic_Heartbeat_Handler,
@@ -1168,3 +2034,77 @@ var _Logic_serviceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "logic/logic.proto",
 }
+
+// BackendClient is the client API for Backend service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BackendClient interface {
+	// Receive
+	Receive(ctx context.Context, in *ReceiveMsg, opts ...grpc.CallOption) (*BackendReply, error)
+}
+
+type backendClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBackendClient(cc *grpc.ClientConn) BackendClient {
+	return &backendClient{cc}
+}
+
+func (c *backendClient) Receive(ctx context.Context, in *ReceiveMsg, opts ...grpc.CallOption) (*BackendReply, error) {
+	out := new(BackendReply)
+	err := c.cc.Invoke(ctx, "/goim.logic.Backend/Receive", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BackendServer is the server API for Backend service.
+type BackendServer interface {
+	// Receive
+	Receive(context.Context, *ReceiveMsg) (*BackendReply, error)
+}
+
+// UnimplementedBackendServer can be embedded to have forward compatible implementations.
+type UnimplementedBackendServer struct {
+}
+
+func (*UnimplementedBackendServer) Receive(ctx context.Context, req *ReceiveMsg) (*BackendReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Receive not implemented")
+}
+
+func RegisterBackendServer(s *grpc.Server, srv BackendServer) {
+	s.RegisterService(&_Backend_serviceDesc, srv)
+}
+
+func _Backend_Receive_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReceiveMsg)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BackendServer).Receive(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/goim.logic.Backend/Receive",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BackendServer).Receive(ctx, req.(*ReceiveMsg))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Backend_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "goim.logic.Backend",
+	HandlerType: (*BackendServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Receive",
+			Handler:    _Backend_Receive_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "logic/logic.proto",
+}
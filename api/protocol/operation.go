@@ -46,4 +46,33 @@ const (
 	OpUnsub = int32(16)
 	// OpUnsubReply unsubscribe operation reply
 	OpUnsubReply = int32(17)
+
+	// OpAuthRefresh ask the client to refresh the auth token by OpAuth
+	OpAuthRefresh = int32(18)
+
+	// OpJoinRoom join a room besides the current room
+	OpJoinRoom = int32(19)
+	// OpJoinRoomReply join room reply
+	OpJoinRoomReply = int32(20)
+	// OpLeaveRoom leave a joined room
+	OpLeaveRoom = int32(21)
+	// OpLeaveRoomReply leave room reply
+	OpLeaveRoomReply = int32(22)
+
+	// OpSubTopic subscribe the named topics
+	OpSubTopic = int32(23)
+	// OpSubTopicReply subscribe topic reply
+	OpSubTopicReply = int32(24)
+	// OpUnsubTopic unsubscribe the named topics
+	OpUnsubTopic = int32(25)
+	// OpUnsubTopicReply unsubscribe topic reply
+	OpUnsubTopicReply = int32(26)
+
+	// OpSync sync the offline messages after the last seen seq
+	OpSync = int32(27)
+	// OpSyncReply sync reply, the body is the packed status and protos
+	OpSyncReply = int32(28)
+
+	// OpPushGap server pushes dropped by comet, the body is the dropped count
+	OpPushGap = int32(29)
 )
@@ -12,6 +12,8 @@ import (
 const (
 	// MaxBodySize max proto body size
 	MaxBodySize = int32(1 << 12)
+	// MaxPackSize max proto pack size
+	MaxPackSize = MaxBodySize + int32(_rawHeaderSize)
 )
 
 const (
@@ -47,6 +49,45 @@ var (
 	ProtoFinish = &Proto{Op: OpProtoFinish}
 )
 
+// Stamp return a copy of the proto with the seq of next, every packed proto
+// of OpRaw gets its own seq, the body shared by others is never modified.
+func (p *Proto) Stamp(next func() int32) *Proto {
+	if p.Op != OpRaw {
+		return &Proto{Ver: p.Ver, Op: p.Op, Seq: next(), Body: p.Body, Trace: p.Trace}
+	}
+	body := make([]byte, len(p.Body))
+	copy(body, p.Body)
+	for offset := 0; offset+_rawHeaderSize <= len(body); {
+		packLen := int(binary.BigEndian.Int32(body[offset+_packOffset:]))
+		if packLen < _rawHeaderSize {
+			break
+		}
+		binary.BigEndian.PutInt32(body[offset+_seqOffset:], next())
+		offset += packLen
+	}
+	return &Proto{Ver: p.Ver, Op: p.Op, Seq: p.Seq, Body: body, Trace: p.Trace}
+}
+
+// Packs get the number of the packed protos of OpRaw, one of the others.
+func (p *Proto) Packs() (n int) {
+	if p.Op != OpRaw {
+		return 1
+	}
+	for offset := 0; offset+_rawHeaderSize <= len(p.Body); n++ {
+		packLen := int(binary.BigEndian.Int32(p.Body[offset+_packOffset:]))
+		if packLen < _rawHeaderSize {
+			break
+		}
+		offset += packLen
+	}
+	return
+}
+
+// PackSize get the size of the proto written by WriteTo.
+func (p *Proto) PackSize() int {
+	return _rawHeaderSize + len(p.Body)
+}
+
 // WriteTo write a proto to bytes writer.
 func (p *Proto) WriteTo(b *bytes.Writer) {
 	var (
@@ -119,14 +160,15 @@ func (p *Proto) WriteTCP(wr *bufio.Writer) (err error) {
 	return
 }
 
-// WriteTCPHeart write TCP heartbeat with room online.
-func (p *Proto) WriteTCPHeart(wr *bufio.Writer, online int32) (err error) {
+// WriteTCPHeart write TCP heartbeat with room online, the online of the
+// joined rooms follows if not nil.
+func (p *Proto) WriteTCPHeart(wr *bufio.Writer, online int32, rooms []byte) (err error) {
 	var (
 		buf     []byte
 		packLen int
 	)
-	packLen = _rawHeaderSize + _heartSize
-	if buf, err = wr.Peek(packLen); err != nil {
+	packLen = _rawHeaderSize + _heartSize + len(rooms)
+	if buf, err = wr.Peek(_rawHeaderSize + _heartSize); err != nil {
 		return
 	}
 	// header
@@ -137,6 +179,9 @@ func (p *Proto) WriteTCPHeart(wr *bufio.Writer, online int32) (err error) {
 	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
 	// body
 	binary.BigEndian.PutInt32(buf[_heartOffset:], online)
+	if rooms != nil {
+		_, err = wr.Write(rooms)
+	}
 	return
 }
 
@@ -197,18 +242,19 @@ func (p *Proto) WriteWebsocket(ws *websocket.Conn) (err error) {
 	return
 }
 
-// WriteWebsocketHeart write websocket heartbeat with room online.
-func (p *Proto) WriteWebsocketHeart(wr *websocket.Conn, online int32) (err error) {
+// WriteWebsocketHeart write websocket heartbeat with room online, the online
+// of the joined rooms follows if not nil.
+func (p *Proto) WriteWebsocketHeart(wr *websocket.Conn, online int32, rooms []byte) (err error) {
 	var (
 		buf     []byte
 		packLen int
 	)
-	packLen = _rawHeaderSize + _heartSize
+	packLen = _rawHeaderSize + _heartSize + len(rooms)
 	// websocket header
 	if err = wr.WriteHeader(websocket.BinaryMessage, packLen); err != nil {
 		return
 	}
-	if buf, err = wr.Peek(packLen); err != nil {
+	if buf, err = wr.Peek(_rawHeaderSize + _heartSize); err != nil {
 		return
 	}
 	// proto header
@@ -219,5 +265,8 @@ func (p *Proto) WriteWebsocketHeart(wr *websocket.Conn, online int32) (err error
 	binary.BigEndian.PutInt32(buf[_seqOffset:], p.Seq)
 	// proto body
 	binary.BigEndian.PutInt32(buf[_heartOffset:], online)
+	if rooms != nil {
+		err = wr.WriteBody(rooms)
+	}
 	return
 }
@@ -0,0 +1,78 @@
+package protocol
+
+import (
+	"testing"
+
+	"github.com/Terry-Mao/goim/pkg/bytes"
+	"github.com/Terry-Mao/goim/pkg/encoding/binary"
+	"github.com/stretchr/testify/assert"
+)
+
+func rawProto(bodies ...string) *Proto {
+	buf := bytes.NewWriterSize(64)
+	for _, body := range bodies {
+		p := &Proto{Ver: 1, Op: 1000, Seq: 7, Body: []byte(body)}
+		p.WriteTo(buf)
+	}
+	return &Proto{Ver: 1, Op: OpRaw, Body: buf.Buffer()}
+}
+
+func packSeqs(body []byte) (seqs []int32) {
+	for offset := 0; offset+_rawHeaderSize <= len(body); {
+		packLen := int(binary.BigEndian.Int32(body[offset+_packOffset:]))
+		if packLen < _rawHeaderSize {
+			break
+		}
+		seqs = append(seqs, binary.BigEndian.Int32(body[offset+_seqOffset:]))
+		offset += packLen
+	}
+	return
+}
+
+func counter() func() int32 {
+	var seq int32
+	return func() int32 {
+		seq++
+		return seq
+	}
+}
+
+func TestStamp(t *testing.T) {
+	p := &Proto{Ver: 1, Op: 1000, Seq: 7, Body: []byte("msg")}
+	sp := p.Stamp(counter())
+	assert.Equal(t, int32(1), sp.Seq)
+	assert.Equal(t, int32(7), p.Seq)
+	// every packed proto gets its own seq, the shared body is kept
+	p = rawProto("a", "bb", "ccc")
+	origin := append([]byte(nil), p.Body...)
+	next := counter()
+	sp = p.Stamp(next)
+	assert.Equal(t, []int32{1, 2, 3}, packSeqs(sp.Body))
+	assert.Equal(t, origin, p.Body)
+	sp = p.Stamp(next)
+	assert.Equal(t, []int32{4, 5, 6}, packSeqs(sp.Body))
+	assert.Equal(t, 3, p.Packs())
+	// the truncated tail is copied as is
+	p = rawProto("a", "bb")
+	p.Body = append(p.Body, 0, 0, 0)
+	sp = p.Stamp(counter())
+	assert.Equal(t, []int32{1, 2}, packSeqs(sp.Body))
+	assert.Equal(t, p.Body[len(p.Body)-3:], sp.Body[len(sp.Body)-3:])
+	assert.Equal(t, 2, p.Packs())
+	// an invalid pack length stops stamping
+	p = rawProto("a")
+	bad := make([]byte, _rawHeaderSize)
+	p.Body = append(p.Body, bad...)
+	sp = p.Stamp(counter())
+	assert.Equal(t, []int32{1}, packSeqs(sp.Body))
+	assert.Equal(t, 1, p.Packs())
+	assert.Equal(t, 1, (&Proto{Op: 1000}).Packs())
+}
+
+func TestPackSize(t *testing.T) {
+	p := &Proto{Ver: 1, Op: 1000, Seq: 7, Body: []byte("msg")}
+	buf := bytes.NewWriterSize(64)
+	p.WriteTo(buf)
+	assert.Equal(t, len(buf.Buffer()), p.PackSize())
+	assert.Equal(t, _rawHeaderSize, (&Proto{}).PackSize())
+}
@@ -11,6 +11,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 	"github.com/Terry-Mao/goim/internal/comet/grpc"
 	md "github.com/Terry-Mao/goim/internal/logic/model"
 	"github.com/Terry-Mao/goim/pkg/ip"
+	"github.com/Terry-Mao/goim/pkg/trace"
 	log "github.com/golang/glog"
 )
 
@@ -38,28 +40,42 @@ func main() {
 	runtime.GOMAXPROCS(runtime.NumCPU())
 	println(conf.Conf.Debug)
 	log.Infof("goim-comet [version: %s env: %+v] start", ver, conf.Conf.Env)
+	trace.Init(conf.Conf.Trace, appid)
 	// register discovery
 	dis := naming.New(conf.Conf.Discovery)
 	resolver.Register(dis)
 	// new comet server
 	srv := comet.NewServer(conf.Conf)
-	if err := comet.InitWhitelist(conf.Conf.Whitelist); err != nil {
-		panic(err)
-	}
 	if err := comet.InitTCP(srv, conf.Conf.TCP.Bind, runtime.NumCPU()); err != nil {
 		panic(err)
 	}
+	if conf.Conf.TCP.TLSOpen {
+		if err := comet.InitTCPWithTLS(srv, conf.Conf.TCP.TLSBind, conf.Conf.TCP.CertFile, conf.Conf.TCP.PrivateFile, runtime.NumCPU()); err != nil {
+			panic(err)
+		}
+	}
 	if err := comet.InitWebsocket(srv, conf.Conf.Websocket.Bind, runtime.NumCPU()); err != nil {
 		panic(err)
 	}
+	if conf.Conf.SSE.Open {
+		if err := comet.InitSSE(srv, conf.Conf.SSE.Bind); err != nil {
+			panic(err)
+		}
+	}
 	if conf.Conf.Websocket.TLSOpen {
 		if err := comet.InitWebsocketWithTLS(srv, conf.Conf.Websocket.TLSBind, conf.Conf.Websocket.CertFile, conf.Conf.Websocket.PrivateFile, runtime.NumCPU()); err != nil {
 			panic(err)
 		}
 	}
+	if err := comet.InitHTTP(srv, conf.Conf.HTTPServer); err != nil {
+		panic(err)
+	}
+	if err := comet.InitAdmin(srv, conf.Conf.Admin); err != nil {
+		panic(err)
+	}
 	// new grpc server
 	rpcSrv := grpc.New(conf.Conf.RPCServer, srv)
-	cancel := register(dis, srv)
+	cancel, offline := register(dis, srv)
 	// signal
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGHUP, syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT)
@@ -68,22 +84,29 @@ func main() {
 		log.Infof("goim-comet get a signal %s", s.String())
 		switch s {
 		case syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT:
+			// offline first, the clients must not reconnect to here
+			offline()
+			srv.Close()
 			if cancel != nil {
 				cancel()
 			}
 			rpcSrv.GracefulStop()
-			srv.Close()
+			trace.Close()
 			log.Infof("goim-comet [version: %s] exit", ver)
 			log.Flush()
 			return
 		case syscall.SIGHUP:
+			if err := srv.ReloadCerts(); err != nil {
+				log.Errorf("srv.ReloadCerts() error(%v)", err)
+			}
 		default:
 			return
 		}
 	}
 }
 
-func register(dis *naming.Discovery, srv *comet.Server) context.CancelFunc {
+func register(dis *naming.Discovery, srv *comet.Server) (context.CancelFunc, func()) {
+	var mu sync.Mutex
 	env := conf.Conf.Env
 	addr := ip.InternalIP()
 	_, port, _ := net.SplitHostPort(conf.Conf.RPCServer.Addr)
@@ -120,9 +143,12 @@ func register(dis *naming.Discovery, srv *comet.Server) context.CancelFunc {
 				}
 				conns += bucket.ChannelCount()
 			}
+			mu.Lock()
 			ins.Metadata[md.MetaConnCount] = fmt.Sprint(conns)
 			ins.Metadata[md.MetaIPCount] = fmt.Sprint(len(ips))
-			if err = dis.Set(ins); err != nil {
+			err = dis.Set(ins)
+			mu.Unlock()
+			if err != nil {
 				log.Errorf("dis.Set(%+v) error(%v)", ins, err)
 				time.Sleep(time.Second)
 				continue
@@ -130,5 +156,14 @@ func register(dis *naming.Discovery, srv *comet.Server) context.CancelFunc {
 			time.Sleep(time.Second * 10)
 		}
 	}()
-	return cancel
+	// flip the offline metadata when draining
+	offline := func() {
+		mu.Lock()
+		ins.Metadata[md.MetaOffline] = strconv.FormatBool(true)
+		if err := dis.Set(ins); err != nil {
+			log.Errorf("dis.Set(%+v) error(%v)", ins, err)
+		}
+		mu.Unlock()
+	}
+	return cancel, offline
 }
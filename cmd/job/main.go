@@ -9,6 +9,7 @@ import (
 	"github.com/bilibili/discovery/naming"
 	"github.com/Terry-Mao/goim/internal/job"
 	"github.com/Terry-Mao/goim/internal/job/conf"
+	"github.com/Terry-Mao/goim/pkg/trace"
 
 	resolver "github.com/bilibili/discovery/naming/grpc"
 	log "github.com/golang/glog"
@@ -24,11 +25,15 @@ func main() {
 		panic(err)
 	}
 	log.Infof("goim-job [version: %s env: %+v] start", ver, conf.Conf.Env)
+	trace.Init(conf.Conf.Trace, "goim.job")
 	// grpc register naming
 	dis := naming.New(conf.Conf.Discovery)
 	resolver.Register(dis)
 	// job
 	j := job.New(conf.Conf)
+	if err := job.InitHTTP(j, conf.Conf.HTTPServer); err != nil {
+		panic(err)
+	}
 	go j.Consume()
 	// signal
 	c := make(chan os.Signal, 1)
@@ -39,6 +44,7 @@ func main() {
 		switch s {
 		case syscall.SIGQUIT, syscall.SIGTERM, syscall.SIGINT:
 			j.Close()
+			trace.Close()
 			log.Infof("goim-job [version: %s] exit", ver)
 			log.Flush()
 			return
@@ -17,6 +17,7 @@ import (
 	"github.com/Terry-Mao/goim/internal/logic/http"
 	"github.com/Terry-Mao/goim/internal/logic/model"
 	"github.com/Terry-Mao/goim/pkg/ip"
+	"github.com/Terry-Mao/goim/pkg/trace"
 	log "github.com/golang/glog"
 )
 
@@ -31,6 +32,7 @@ func main() {
 		panic(err)
 	}
 	log.Infof("goim-logic [version: %s env: %+v] start", ver, conf.Conf.Env)
+	trace.Init(conf.Conf.Trace, appid)
 	// grpc register naming
 	dis := naming.New(conf.Conf.Discovery)
 	resolver.Register(dis)
@@ -53,10 +55,14 @@ func main() {
 			srv.Close()
 			httpSrv.Close()
 			rpcSrv.GracefulStop()
+			trace.Close()
 			log.Infof("goim-logic [version: %s] exit", ver)
 			log.Flush()
 			return
 		case syscall.SIGHUP:
+			if err := srv.ReloadAuthKeys(); err != nil {
+				log.Errorf("srv.ReloadAuthKeys() error(%v)", err)
+			}
 		default:
 			return
 		}
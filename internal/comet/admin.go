@@ -0,0 +1,362 @@
+package comet
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/comet/conf"
+	log "github.com/golang/glog"
+)
+
+const (
+	_adminOK           = 0
+	_adminRequestErr   = -400
+	_adminUnauthorized = -401
+	_adminPageSize     = 20
+	_adminMaxPage      = 500
+)
+
+// ChannelInfo is the state of a live channel.
+type ChannelInfo struct {
+	Key        string   `json:"key"`
+	Mid        int64    `json:"mid"`
+	IP         string   `json:"ip"`
+	Room       string   `json:"room"`        // the current room
+	Rooms      []string `json:"rooms"`       // all joined rooms
+	Ops        []int32  `json:"ops"`         // watched ops
+	Connected  int64    `json:"connected"`   // connect unix time
+	Ring       int      `json:"ring"`        // pending client protos
+	RingSize   int      `json:"ring_size"`   // client proto ring size
+	Signal     int      `json:"signal"`      // pending server pushes
+	SignalSize int      `json:"signal_size"` // server push signal size
+	Overflow   int      `json:"overflow"`    // pending server pushes in the overflow buffer
+}
+
+// BucketStat is the stat of a bucket.
+type BucketStat struct {
+	Bucket    int `json:"bucket"`
+	Channels  int `json:"channels"`
+	Rooms     int `json:"rooms"`
+	Topics    int `json:"topics"`
+	IPs       int `json:"ips"`
+	RoomQueue int `json:"room_queue"` // pending room broadcasts
+}
+
+type adminResp struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// MidChannels get the channels of the mid in all buckets.
+func (s *Server) MidChannels(mid int64) (chs []*Channel) {
+	for _, b := range s.buckets {
+		chs = append(chs, b.MidChannels(mid)...)
+	}
+	return
+}
+
+// RoomsCount get the online number of the rooms in all buckets.
+func (s *Server) RoomsCount() (res map[string]int32) {
+	res = make(map[string]int32)
+	for _, b := range s.buckets {
+		for rid, n := range b.RoomsCount() {
+			res[rid] += n
+		}
+	}
+	return
+}
+
+// RoomChannels get the channels of the room in all buckets, sorted by key.
+func (s *Server) RoomChannels(rid string) (chs []*Channel) {
+	for _, b := range s.buckets {
+		chs = append(chs, b.RoomChannels(rid)...)
+	}
+	sort.Slice(chs, func(i, j int) bool { return chs[i].Key < chs[j].Key })
+	return
+}
+
+// BucketStats get the stats of all buckets.
+func (s *Server) BucketStats() (stats []*BucketStat) {
+	stats = make([]*BucketStat, 0, len(s.buckets))
+	for i, b := range s.buckets {
+		stat := b.Stat()
+		stat.Bucket = i
+		stats = append(stats, stat)
+	}
+	return
+}
+
+// admin serve the admin api of the live connections.
+type admin struct {
+	srv   *Server
+	token string
+}
+
+// InitAdmin listen the admin server and serve the admin api at /admin.
+func InitAdmin(server *Server, c *conf.Admin) (err error) {
+	var listener net.Listener
+	mux := http.NewServeMux()
+	a := registerAdmin(mux, server)
+	a.token = c.Token
+	if listener, err = net.Listen(c.Network, c.Addr); err != nil {
+		log.Errorf("net.Listen(%s, %s) error(%v)", c.Network, c.Addr, err)
+		return
+	}
+	log.Infof("start admin listen: %s", c.Addr)
+	srv := &http.Server{
+		Handler:     a.auth(mux),
+		ReadTimeout: time.Duration(c.ReadTimeout),
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.Errorf("admin serve(%s) error(%v)", c.Addr, err)
+		}
+	}()
+	return
+}
+
+func registerAdmin(mux *http.ServeMux, srv *Server) (a *admin) {
+	a = &admin{srv: srv}
+	mux.HandleFunc("/admin/channel", a.channel)
+	mux.HandleFunc("/admin/rooms", a.rooms)
+	mux.HandleFunc("/admin/room", a.room)
+	mux.HandleFunc("/admin/buckets", a.buckets)
+	mux.HandleFunc("/admin/debug/enable", a.debugEnable)
+	mux.HandleFunc("/admin/debug/disable", a.debugDisable)
+	mux.HandleFunc("/admin/debug/sessions", a.debugSessions)
+	mux.HandleFunc("/admin/debug/events", a.debugEvents)
+	return
+}
+
+// auth check the bearer token if configured.
+func (a *admin) auth(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.token != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+a.token)) != 1 {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.WriteHeader(http.StatusUnauthorized)
+			a.errors(w, _adminUnauthorized, "unauthorized")
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (a *admin) result(w http.ResponseWriter, data interface{}) {
+	a.write(w, adminResp{Code: _adminOK, Data: data})
+}
+
+func (a *admin) errors(w http.ResponseWriter, code int, msg string) {
+	a.write(w, adminResp{Code: code, Message: msg})
+}
+
+func (a *admin) write(w http.ResponseWriter, res adminResp) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// channel get the channels by key or mid.
+func (a *admin) channel(w http.ResponseWriter, r *http.Request) {
+	var (
+		chs   []*Channel
+		query = r.URL.Query()
+	)
+	if key := query.Get("key"); key != "" {
+		if ch := a.srv.Bucket(key).Channel(key); ch != nil {
+			chs = append(chs, ch)
+		}
+	} else if v := query.Get("mid"); v != "" {
+		mid, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			a.errors(w, _adminRequestErr, err.Error())
+			return
+		}
+		chs = a.srv.MidChannels(mid)
+	} else {
+		a.errors(w, _adminRequestErr, "key or mid required")
+		return
+	}
+	infos := make([]*ChannelInfo, 0, len(chs))
+	for _, ch := range chs {
+		infos = append(infos, ch.Info())
+	}
+	a.result(w, infos)
+}
+
+// rooms get the online number of the rooms.
+func (a *admin) rooms(w http.ResponseWriter, r *http.Request) {
+	a.result(w, a.srv.RoomsCount())
+}
+
+// room get the members of the room by page.
+func (a *admin) room(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		pn, ps = 1, _adminPageSize
+		query  = r.URL.Query()
+		rid    = query.Get("room")
+	)
+	if rid == "" {
+		a.errors(w, _adminRequestErr, "room required")
+		return
+	}
+	if v := query.Get("pn"); v != "" {
+		if pn, err = strconv.Atoi(v); err != nil || pn < 1 {
+			a.errors(w, _adminRequestErr, "invalid pn")
+			return
+		}
+	}
+	if v := query.Get("ps"); v != "" {
+		if ps, err = strconv.Atoi(v); err != nil || ps < 1 || ps > _adminMaxPage {
+			a.errors(w, _adminRequestErr, "invalid ps")
+			return
+		}
+	}
+	chs := a.srv.RoomChannels(rid)
+	members := make([]*ChannelInfo, 0, ps)
+	// check the page before the offset, a large pn overflows it
+	if pn-1 <= len(chs)/ps {
+		for i := (pn - 1) * ps; i < len(chs) && i < pn*ps; i++ {
+			members = append(members, chs[i].Info())
+		}
+	}
+	a.result(w, map[string]interface{}{
+		"total":   len(chs),
+		"members": members,
+	})
+}
+
+// buckets get the stats of the buckets.
+func (a *admin) buckets(w http.ResponseWriter, r *http.Request) {
+	a.result(w, a.srv.BucketStats())
+}
+
+// debugTarget get the session target of the mid, key or ip.
+func debugTarget(r *http.Request) (kind, target string) {
+	query := r.URL.Query()
+	for _, kind = range []string{DebugMid, DebugKey, DebugIP} {
+		if target = query.Get(kind); target != "" {
+			return
+		}
+	}
+	return "", ""
+}
+
+// debugEnable start or renew a debug session of the mid, key or ip.
+func (a *admin) debugEnable(w http.ResponseWriter, r *http.Request) {
+	var expire time.Duration
+	if r.Method != http.MethodPost {
+		a.errors(w, _adminRequestErr, "POST required")
+		return
+	}
+	kind, target := debugTarget(r)
+	if kind == "" {
+		a.errors(w, _adminRequestErr, "mid, key or ip required")
+		return
+	}
+	if v := r.URL.Query().Get("expire"); v != "" {
+		var err error
+		if expire, err = time.ParseDuration(v); err != nil {
+			a.errors(w, _adminRequestErr, err.Error())
+			return
+		}
+	}
+	if err := a.srv.debug.Enable(kind, target, expire); err != nil {
+		a.errors(w, _adminRequestErr, err.Error())
+		return
+	}
+	a.result(w, nil)
+}
+
+// debugDisable stop the debug session of the mid, key or ip.
+func (a *admin) debugDisable(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		a.errors(w, _adminRequestErr, "POST required")
+		return
+	}
+	kind, target := debugTarget(r)
+	if kind == "" {
+		a.errors(w, _adminRequestErr, "mid, key or ip required")
+		return
+	}
+	if err := a.srv.debug.Disable(kind, target); err != nil {
+		a.errors(w, _adminRequestErr, err.Error())
+		return
+	}
+	a.result(w, nil)
+}
+
+// debugSessions get the debug sessions and the traced connections.
+func (a *admin) debugSessions(w http.ResponseWriter, r *http.Request) {
+	sessions, keys := a.srv.debug.Sessions()
+	sort.Strings(keys)
+	a.result(w, map[string]interface{}{
+		"sessions": sessions,
+		"keys":     keys,
+	})
+}
+
+// debugEvents get the events after the seq of a traced connection, the
+// events are streamed as json lines until the connection is no longer traced
+// if follow.
+func (a *admin) debugEvents(w http.ResponseWriter, r *http.Request) {
+	var (
+		err   error
+		seq   uint64
+		query = r.URL.Query()
+		key   = query.Get("key")
+	)
+	if key == "" {
+		a.errors(w, _adminRequestErr, "key required")
+		return
+	}
+	if v := query.Get("seq"); v != "" {
+		if seq, err = strconv.ParseUint(v, 10, 64); err != nil {
+			a.errors(w, _adminRequestErr, err.Error())
+			return
+		}
+	}
+	events, notify, done, ok := a.srv.debug.Events(key, seq)
+	if !ok {
+		a.errors(w, _adminRequestErr, "key not traced")
+		return
+	}
+	if query.Get("follow") == "" {
+		if events == nil {
+			events = []DebugEvent{}
+		}
+		a.result(w, events)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		a.errors(w, _adminRequestErr, "streaming unsupported")
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	enc := json.NewEncoder(w)
+	for {
+		for _, e := range events {
+			if err = enc.Encode(e); err != nil {
+				return
+			}
+			seq = e.Seq
+		}
+		flusher.Flush()
+		select {
+		case <-notify:
+		case <-done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+		if events, notify, _, ok = a.srv.debug.Events(key, seq); !ok {
+			return
+		}
+	}
+}
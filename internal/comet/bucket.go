@@ -7,6 +7,7 @@ import (
 	pb "github.com/Terry-Mao/goim/api/comet"
 	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/comet/conf"
+	"github.com/Terry-Mao/goim/internal/comet/errors"
 )
 
 // Bucket is a channel holder.
@@ -20,6 +21,9 @@ type Bucket struct {
 	routinesNum uint64
 
 	ipCnts map[string]int32
+	// topic
+	tLock  sync.RWMutex
+	topics map[string]map[*Channel]struct{} // topic index of the subscribed channels
 }
 
 // NewBucket new a bucket struct. store the key with im channel.
@@ -27,6 +31,7 @@ func NewBucket(c *conf.Bucket) (b *Bucket) {
 	b = new(Bucket)
 	b.chs = make(map[string]*Channel, c.Channel)
 	b.ipCnts = make(map[string]int32)
+	b.topics = make(map[string]map[*Channel]struct{})
 	b.c = c
 	b.rooms = make(map[string]*Room, c.Room)
 	b.routines = make([]chan *pb.BroadcastRoomReq, c.RoutineAmount)
@@ -65,68 +70,126 @@ func (b *Bucket) RoomsCount() (res map[string]int32) {
 	return
 }
 
-// ChangeRoom change ro room
+// ChangeRoom change the current room, the other joined rooms are kept.
 func (b *Bucket) ChangeRoom(nrid string, ch *Channel) (err error) {
 	var (
-		nroom *Room
-		ok    bool
+		room  *Room
+		left  int
 		oroom = ch.Room
 	)
+	if oroom != nil {
+		if oroom.ID == nrid {
+			return
+		}
+		left = 1
+	}
+	// check before leaving the current room
+	if nrid != "" && b.roomsFull(nrid, ch, left) {
+		return errors.ErrRoomsFull
+	}
+	if oroom != nil {
+		b.leave(oroom.ID, ch)
+		ch.setRoom(nil)
+	}
 	// change to no room
 	if nrid == "" {
-		if oroom != nil && oroom.Del(ch) {
-			b.DelRoom(oroom)
-		}
-		ch.Room = nil
 		return
 	}
+	if room, err = b.join(nrid, ch); err != nil {
+		return
+	}
+	ch.setRoom(room)
+	return
+}
+
+// JoinRoom join a room besides the current room.
+func (b *Bucket) JoinRoom(rid string, ch *Channel) (err error) {
+	if rid == "" {
+		return errors.ErrRoomID
+	}
+	if b.roomsFull(rid, ch, 0) {
+		return errors.ErrRoomsFull
+	}
+	_, err = b.join(rid, ch)
+	return
+}
+
+// roomsFull check if the channel may not join one more room after leaving
+// the left rooms.
+func (b *Bucket) roomsFull(rid string, ch *Channel, left int) bool {
+	if _, ok := ch.members[rid]; ok || b.c.MaxRooms <= 0 {
+		return false
+	}
+	return len(ch.members)-left >= b.c.MaxRooms
+}
+
+// LeaveRoom leave a joined room, the current room is left too if it is.
+func (b *Bucket) LeaveRoom(rid string, ch *Channel) {
+	b.leave(rid, ch)
+	if ch.Room != nil && ch.Room.ID == rid {
+		ch.setRoom(nil)
+	}
+}
+
+// join put the channel into the room if not a member.
+func (b *Bucket) join(rid string, ch *Channel) (room *Room, err error) {
+	var (
+		m  *member
+		ok bool
+	)
+	if m, ok = ch.members[rid]; ok {
+		return m.room, nil
+	}
 	b.cLock.Lock()
-	if nroom, ok = b.rooms[nrid]; !ok {
-		nroom = NewRoom(nrid)
-		b.rooms[nrid] = nroom
+	if room, ok = b.rooms[rid]; !ok {
+		room = NewRoom(rid)
+		b.rooms[rid] = room
 	}
 	b.cLock.Unlock()
-	if oroom != nil && oroom.Del(ch) {
-		b.DelRoom(oroom)
+	if m, err = room.Put(ch); err != nil {
+		return
 	}
+	ch.mutex.Lock()
+	ch.members[rid] = m
+	ch.mutex.Unlock()
+	return
+}
 
-	if err = nroom.Put(ch); err != nil {
+// leave delete the channel from the room if a member.
+func (b *Bucket) leave(rid string, ch *Channel) {
+	m, ok := ch.members[rid]
+	if !ok {
 		return
 	}
-	ch.Room = nroom
-	return
+	ch.mutex.Lock()
+	delete(ch.members, rid)
+	ch.mutex.Unlock()
+	if m.room.Del(m) {
+		// if empty room, must delete from bucket
+		b.DelRoom(m.room)
+	}
 }
 
 // Put put a channel according with sub key.
 func (b *Bucket) Put(rid string, ch *Channel) (err error) {
-	var (
-		room *Room
-		ok   bool
-	)
 	b.cLock.Lock()
 	// close old channel
 	if dch := b.chs[ch.Key]; dch != nil {
 		dch.Close()
 	}
 	b.chs[ch.Key] = ch
-	if rid != "" {
-		if room, ok = b.rooms[rid]; !ok {
-			room = NewRoom(rid)
-			b.rooms[rid] = room
-		}
-		ch.Room = room
-	}
 	b.ipCnts[ch.IP]++
 	b.cLock.Unlock()
-	if room != nil {
-		err = room.Put(ch)
+	if rid != "" {
+		var room *Room
+		room, err = b.join(rid, ch)
+		ch.setRoom(room)
 	}
 	return
 }
 
-// Del delete the channel by sub key.
+// Del delete the channel by sub key and leave all the joined rooms.
 func (b *Bucket) Del(dch *Channel) {
-	room := dch.Room
 	b.cLock.Lock()
 	if ch, ok := b.chs[dch.Key]; ok {
 		if ch == dch {
@@ -140,10 +203,73 @@ func (b *Bucket) Del(dch *Channel) {
 		}
 	}
 	b.cLock.Unlock()
-	if room != nil && room.Del(dch) {
-		// if empty room, must delete from bucket
-		b.DelRoom(room)
+	for rid := range dch.members {
+		b.leave(rid, dch)
 	}
+	b.Unsubscribe(dch)
+}
+
+// Subscribe subscribe the topics of the channel.
+func (b *Bucket) Subscribe(ch *Channel, topics ...string) (err error) {
+	b.tLock.Lock()
+	for _, topic := range topics {
+		if _, ok := ch.topics[topic]; ok || topic == "" {
+			continue
+		}
+		if b.c.MaxTopics > 0 && len(ch.topics) >= b.c.MaxTopics {
+			err = errors.ErrTopicsFull
+			break
+		}
+		chs, ok := b.topics[topic]
+		if !ok {
+			chs = make(map[*Channel]struct{})
+			b.topics[topic] = chs
+		}
+		chs[ch] = struct{}{}
+		ch.topics[topic] = struct{}{}
+	}
+	b.tLock.Unlock()
+	return
+}
+
+// Unsubscribe unsubscribe the topics of the channel, all if no topics.
+func (b *Bucket) Unsubscribe(ch *Channel, topics ...string) {
+	if len(topics) == 0 {
+		for topic := range ch.topics {
+			topics = append(topics, topic)
+		}
+	}
+	b.tLock.Lock()
+	for _, topic := range topics {
+		if _, ok := ch.topics[topic]; !ok {
+			continue
+		}
+		delete(ch.topics, topic)
+		if chs := b.topics[topic]; chs != nil {
+			delete(chs, ch)
+			if len(chs) == 0 {
+				delete(b.topics, topic)
+			}
+		}
+	}
+	b.tLock.Unlock()
+}
+
+// PushTopic push msgs to the subscribed channels of the topic in the bucket.
+func (b *Bucket) PushTopic(topic string, p *protocol.Proto) {
+	b.tLock.RLock()
+	for ch := range b.topics[topic] {
+		_ = ch.Push(p)
+	}
+	b.tLock.RUnlock()
+}
+
+// TopicCount topic count in the bucket.
+func (b *Bucket) TopicCount() int {
+	b.tLock.RLock()
+	n := len(b.topics)
+	b.tLock.RUnlock()
+	return n
 }
 
 // Channel get a channel by sub key.
@@ -154,6 +280,43 @@ func (b *Bucket) Channel(key string) (ch *Channel) {
 	return
 }
 
+// Channels get all channels in the bucket.
+func (b *Bucket) Channels() (chs []*Channel) {
+	b.cLock.RLock()
+	chs = make([]*Channel, 0, len(b.chs))
+	for _, ch := range b.chs {
+		chs = append(chs, ch)
+	}
+	b.cLock.RUnlock()
+	return
+}
+
+// MidChannels get the channels of the mid in the bucket.
+func (b *Bucket) MidChannels(mid int64) (chs []*Channel) {
+	b.cLock.RLock()
+	for _, ch := range b.chs {
+		if ch.Mid == mid {
+			chs = append(chs, ch)
+		}
+	}
+	b.cLock.RUnlock()
+	return
+}
+
+// IPChannels get the channels of the ip in the bucket.
+func (b *Bucket) IPChannels(ip string) (chs []*Channel) {
+	b.cLock.RLock()
+	if b.ipCnts[ip] > 0 {
+		for _, ch := range b.chs {
+			if ch.IP == ip {
+				chs = append(chs, ch)
+			}
+		}
+	}
+	b.cLock.RUnlock()
+	return
+}
+
 // Broadcast push msgs to all channels in the bucket.
 func (b *Bucket) Broadcast(p *protocol.Proto, op int32) {
 	var ch *Channel
@@ -175,6 +338,14 @@ func (b *Bucket) Room(rid string) (room *Room) {
 	return
 }
 
+// RoomChannels get the channels of the room in the bucket.
+func (b *Bucket) RoomChannels(rid string) (chs []*Channel) {
+	if room := b.Room(rid); room != nil {
+		chs = room.Channels()
+	}
+	return
+}
+
 // DelRoom delete a room by roomid.
 func (b *Bucket) DelRoom(room *Room) {
 	b.cLock.Lock()
@@ -233,6 +404,21 @@ func (b *Bucket) UpRoomsCount(roomCountMap map[string]int32) {
 	b.cLock.RUnlock()
 }
 
+// Stat get the stat of the bucket.
+func (b *Bucket) Stat() (s *BucketStat) {
+	s = new(BucketStat)
+	b.cLock.RLock()
+	s.Channels = len(b.chs)
+	s.Rooms = len(b.rooms)
+	s.IPs = len(b.ipCnts)
+	b.cLock.RUnlock()
+	s.Topics = b.TopicCount()
+	for _, c := range b.routines {
+		s.RoomQueue += len(c)
+	}
+	return
+}
+
 // roomproc
 func (b *Bucket) roomproc(c chan *pb.BroadcastRoomReq) {
 	for {
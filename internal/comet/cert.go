@@ -0,0 +1,101 @@
+package comet
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"strings"
+	"sync"
+
+	"github.com/Terry-Mao/goim/internal/comet/errors"
+	log "github.com/golang/glog"
+)
+
+// Certs holds the tls certificates of a listener, selects one by SNI and
+// reloads them from disk without dropping the established connections.
+type Certs struct {
+	certFiles    []string
+	privateFiles []string
+
+	lock       sync.RWMutex
+	certs      []tls.Certificate
+	nameToCert map[string]*tls.Certificate
+}
+
+// NewCerts load the comma separated cert and private key files.
+func NewCerts(certFile, privateFile string) (c *Certs, err error) {
+	c = &Certs{
+		certFiles:    strings.Split(certFile, ","),
+		privateFiles: strings.Split(privateFile, ","),
+	}
+	if err = c.Reload(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Reload reload the certificates from disk, the loaded ones are kept if
+// failed or none is loaded.
+func (c *Certs) Reload() (err error) {
+	var (
+		cert       tls.Certificate
+		leaf       *x509.Certificate
+		certs      []tls.Certificate
+		nameToCert = make(map[string]*tls.Certificate)
+	)
+	for i := range c.certFiles {
+		if i >= len(c.privateFiles) {
+			break
+		}
+		if cert, err = tls.LoadX509KeyPair(c.certFiles[i], c.privateFiles[i]); err != nil {
+			log.Errorf("Error loading certificate(%s). error(%v)", c.certFiles[i], err)
+			return
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		log.Errorf("no certificate loaded from %v", c.certFiles)
+		return errors.ErrNoCertificate
+	}
+	for i := range certs {
+		if leaf, err = x509.ParseCertificate(certs[i].Certificate[0]); err != nil {
+			log.Errorf("x509.ParseCertificate(%s) error(%v)", c.certFiles[i], err)
+			return
+		}
+		if len(leaf.Subject.CommonName) > 0 && len(leaf.DNSNames) == 0 {
+			nameToCert[strings.ToLower(leaf.Subject.CommonName)] = &certs[i]
+		}
+		for _, san := range leaf.DNSNames {
+			nameToCert[strings.ToLower(san)] = &certs[i]
+		}
+	}
+	c.lock.Lock()
+	c.certs = certs
+	c.nameToCert = nameToCert
+	c.lock.Unlock()
+	return
+}
+
+// TLSConfig returns a tls config using the certificates.
+func (c *Certs) TLSConfig() *tls.Config {
+	return &tls.Config{GetCertificate: c.getCertificate}
+}
+
+// getCertificate select a certificate by SNI, or the first one if no match.
+func (c *Certs) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	name := strings.TrimSuffix(strings.ToLower(hello.ServerName), ".")
+	if cert, ok := c.nameToCert[name]; ok {
+		return cert, nil
+	}
+	// wildcard, e.g. *.goim.io
+	if labels := strings.SplitN(name, ".", 2); len(labels) == 2 {
+		if cert, ok := c.nameToCert["*."+labels[1]]; ok {
+			return cert, nil
+		}
+	}
+	if len(c.certs) == 0 {
+		return nil, errors.ErrNoCertificate
+	}
+	return &c.certs[0], nil
+}
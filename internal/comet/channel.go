@@ -1,39 +1,140 @@
 package comet
 
 import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/comet/errors"
 	"github.com/Terry-Mao/goim/pkg/bufio"
+	log "github.com/golang/glog"
 )
 
 // Channel used by message pusher send msg to write goroutine.
 type Channel struct {
-	Room     *Room
+	Room     *Room // the current room of OpChangeRoom, set under the mutex
 	CliProto Ring
-	signal   chan *protocol.Proto
+	signal   chan *protocol.Proto // server pushes
+	ctrl     chan *protocol.Proto // dispatch signals, never dropped
+	ready    int32                // a ProtoReady is pending in ctrl
 	Writer   bufio.Writer
 	Reader   bufio.Reader
-	Next     *Channel
-	Prev     *Channel
+	members  map[string]*member  // all joined rooms, only changed by the reader under the mutex
+	topics   map[string]struct{} // subscribed topics, only used by the reader
 
 	Mid      int64
 	Key      string
 	IP       string
 	watchOps map[int32]struct{}
 	mutex    sync.RWMutex
+	created  time.Time
+
+	// slow consumer
+	slow      *Slow
+	conn      io.Closer // closed if disconnect the slow client
+	oLock     sync.Mutex
+	overflow  []*protocol.Proto
+	closeOnce sync.Once
+	finish    sync.Once
+	dropped   uint32 // dropped server pushes since the last dispatch
+
+	seq int32 // server push seq, only used by the dispatcher
+
+	expiry *expiry // session expiry, only used by the reader
+
+	debug atomic.Value // *debugLog if traced
+
+	// allowed rooms and ops of the token and authorized by logic, only used
+	// by the reader
+	rooms     map[string]struct{}
+	ops       map[int32]struct{}
+	subTopics map[string]struct{}
 }
 
 // NewChannel new a channel.
-func NewChannel(cli, svr int) *Channel {
+func NewChannel(cli, svr int, slow *Slow) *Channel {
 	c := new(Channel)
 	c.CliProto.Init(cli)
 	c.signal = make(chan *protocol.Proto, svr)
+	// a coalesced ProtoReady and a ProtoFinish at most
+	c.ctrl = make(chan *protocol.Proto, 2)
 	c.watchOps = make(map[int32]struct{})
+	c.members = make(map[string]*member)
+	c.topics = make(map[string]struct{})
+	c.rooms = make(map[string]struct{})
+	c.ops = make(map[int32]struct{})
+	c.subTopics = make(map[string]struct{})
+	c.slow = slow
+	c.created = time.Now()
 	return c
 }
 
+// setRoom set the current room.
+func (c *Channel) setRoom(room *Room) {
+	c.mutex.Lock()
+	c.Room = room
+	c.mutex.Unlock()
+}
+
+// Online get the online number of the current room, and the json of the
+// online number of all the joined rooms if any besides the current room.
+func (c *Channel) Online() (online int32, rooms []byte) {
+	var counts map[string]int32
+	c.mutex.RLock()
+	if c.Room != nil {
+		online = c.Room.OnlineNum()
+	}
+	if n := len(c.members); n > 1 || (n == 1 && c.Room == nil) {
+		counts = make(map[string]int32, n)
+		for rid, m := range c.members {
+			counts[rid] = m.room.OnlineNum()
+		}
+	}
+	c.mutex.RUnlock()
+	if counts != nil {
+		rooms, _ = json.Marshal(counts)
+	}
+	return
+}
+
+// Info get a snapshot of the channel state.
+func (c *Channel) Info() (info *ChannelInfo) {
+	info = &ChannelInfo{
+		Key:        c.Key,
+		Mid:        c.Mid,
+		IP:         c.IP,
+		Connected:  c.created.Unix(),
+		Ring:       c.CliProto.Len(),
+		RingSize:   c.CliProto.Size(),
+		Signal:     len(c.signal),
+		SignalSize: cap(c.signal),
+		Rooms:      []string{},
+		Ops:        []int32{},
+	}
+	c.mutex.RLock()
+	if c.Room != nil {
+		info.Room = c.Room.ID
+	}
+	for rid := range c.members {
+		info.Rooms = append(info.Rooms, rid)
+	}
+	for op := range c.watchOps {
+		info.Ops = append(info.Ops, op)
+	}
+	c.mutex.RUnlock()
+	c.oLock.Lock()
+	info.Overflow = len(c.overflow)
+	c.oLock.Unlock()
+	sort.Strings(info.Rooms)
+	sort.Slice(info.Ops, func(i, j int) bool { return info.Ops[i] < info.Ops[j] })
+	return
+}
+
 // Watch watch a operation.
 func (c *Channel) Watch(accepts ...int32) {
 	c.mutex.Lock()
@@ -52,6 +153,45 @@ func (c *Channel) UnWatch(accepts ...int32) {
 	c.mutex.Unlock()
 }
 
+// AllowRooms add the rooms to the allow-list.
+func (c *Channel) AllowRooms(rooms ...string) {
+	for _, room := range rooms {
+		c.rooms[room] = struct{}{}
+	}
+}
+
+// AllowOps add the ops to the allow-list.
+func (c *Channel) AllowOps(ops ...int32) {
+	for _, op := range ops {
+		c.ops[op] = struct{}{}
+	}
+}
+
+// AllowTopics add the topics to the allow-list.
+func (c *Channel) AllowTopics(topics ...string) {
+	for _, topic := range topics {
+		c.subTopics[topic] = struct{}{}
+	}
+}
+
+// AllowedRoom check if the room in the allow-list.
+func (c *Channel) AllowedRoom(room string) bool {
+	_, ok := c.rooms[room]
+	return ok
+}
+
+// AllowedOp check if the op in the allow-list.
+func (c *Channel) AllowedOp(op int32) bool {
+	_, ok := c.ops[op]
+	return ok
+}
+
+// AllowedTopic check if the topic in the allow-list.
+func (c *Channel) AllowedTopic(topic string) bool {
+	_, ok := c.subTopics[topic]
+	return ok
+}
+
 // NeedPush verify if in watch.
 func (c *Channel) NeedPush(op int32) bool {
 	c.mutex.RLock()
@@ -63,27 +203,136 @@ func (c *Channel) NeedPush(op int32) bool {
 	return false
 }
 
-// Push server push message.
+// Push server push message, if the signal is full handle by the
+// slow-consumer policy.
 func (c *Channel) Push(p *protocol.Proto) (err error) {
+	if c.slow != nil && c.slow.policy == SlowOverflow {
+		return c.pushOverflow(p)
+	}
 	select {
 	case c.signal <- p:
+		return
+	default:
+	}
+	if c.slow == nil {
+		c.drop(p)
+		return errors.ErrSignalFullMsgDropped
+	}
+	switch c.slow.policy {
+	case SlowDropOldest:
+		drop := p
+		select {
+		case old := <-c.signal:
+			select {
+			case c.signal <- p:
+				drop = old
+			default:
+				c.drop(old)
+			}
+		default:
+		}
+		c.drop(drop)
+		if drop == p {
+			err = errors.ErrSignalFullMsgDropped
+		}
+	case SlowDisconnect:
+		c.drop(p)
+		c.closeOnce.Do(func() {
+			log.Errorf("key: %s mid: %d slow consumer disconnected", c.Key, c.Mid)
+			if c.conn != nil {
+				// closing a tls conn may block, never on the pusher
+				go c.conn.Close()
+			}
+		})
+		err = errors.ErrSignalFullMsgDropped
 	default:
+		c.drop(p)
+		err = errors.ErrSignalFullMsgDropped
+	}
+	return
+}
+
+// drop count a dropped server push, every packed proto of OpRaw counts.
+func (c *Channel) drop(p *protocol.Proto) {
+	n := p.Packs()
+	if c.slow != nil {
+		c.slow.Drop(p.Op, n)
+	}
+	pushDrops.WithLabelValues(strconv.Itoa(int(p.Op))).Add(float64(n))
+	atomic.AddUint32(&c.dropped, uint32(n))
+}
+
+// Dropped return and reset the count of the dropped server pushes.
+func (c *Channel) Dropped() int32 {
+	return int32(atomic.SwapUint32(&c.dropped, 0))
+}
+
+// NextSeq return the next server push seq, only used by the dispatcher.
+func (c *Channel) NextSeq() int32 {
+	c.seq++
+	return c.seq
+}
+
+// pushOverflow spill to the overflow buffer if the signal is full, or the
+// buffer is not empty to keep the order.
+func (c *Channel) pushOverflow(p *protocol.Proto) (err error) {
+	c.oLock.Lock()
+	if len(c.overflow) == 0 {
+		select {
+		case c.signal <- p:
+			c.oLock.Unlock()
+			return
+		default:
+		}
+	}
+	if len(c.overflow) < c.slow.overflow {
+		c.overflow = append(c.overflow, p)
+	} else {
+		c.drop(p)
 		err = errors.ErrSignalFullMsgDropped
 	}
+	c.oLock.Unlock()
 	return
 }
 
 // Ready check the channel ready or close?
-func (c *Channel) Ready() *protocol.Proto {
-	return <-c.signal
+func (c *Channel) Ready() (p *protocol.Proto) {
+	select {
+	case p = <-c.signal:
+	case p = <-c.ctrl:
+		if p == protocol.ProtoReady {
+			atomic.StoreInt32(&c.ready, 0)
+		}
+	}
+	if c.slow != nil && c.slow.policy == SlowOverflow {
+		// refill the signal from the overflow buffer
+		c.oLock.Lock()
+		for len(c.overflow) > 0 {
+			select {
+			case c.signal <- c.overflow[0]:
+				c.overflow[0] = nil
+				c.overflow = c.overflow[1:]
+				continue
+			default:
+			}
+			break
+		}
+		c.oLock.Unlock()
+	}
+	return
 }
 
-// Signal send signal to the channel, protocol ready.
+// Signal send signal to the channel, protocol ready, coalesced with the
+// pending one.
 func (c *Channel) Signal() {
-	c.signal <- protocol.ProtoReady
+	if atomic.CompareAndSwapInt32(&c.ready, 0, 1) {
+		c.ctrl <- protocol.ProtoReady
+	}
 }
 
-// Close close the channel.
+// Close close the channel, only the first close is sent.
 func (c *Channel) Close() {
-	c.signal <- protocol.ProtoFinish
+	c.finish.Do(func() {
+		c.ctrl <- protocol.ProtoFinish
+	})
 }
@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"github.com/bilibili/discovery/naming"
 	"github.com/BurntSushi/toml"
 	xtime "github.com/Terry-Mao/goim/pkg/time"
+	"github.com/Terry-Mao/goim/pkg/trace"
 )
 
 var (
@@ -49,7 +51,12 @@ func init() {
 // Init init config.
 func Init() (err error) {
 	Conf = Default()
-	_, err = toml.DecodeFile(confPath, &Conf)
+	if _, err = toml.DecodeFile(confPath, &Conf); err != nil {
+		return
+	}
+	if Conf.ProxyProtocol.Open && len(Conf.ProxyProtocol.Trusted) == 0 {
+		err = errors.New("proxyProtocol.trusted is required if open")
+	}
 	return
 }
 
@@ -63,6 +70,12 @@ func Default() *Config {
 			Dial:    xtime.Duration(time.Second),
 			Timeout: xtime.Duration(time.Second),
 		},
+		HTTPServer: &HTTPServer{
+			Network:      "tcp",
+			Addr:         ":3110",
+			ReadTimeout:  xtime.Duration(time.Second),
+			WriteTimeout: xtime.Duration(time.Second),
+		},
 		RPCServer: &RPCServer{
 			Network:           "tcp",
 			Addr:              ":3109",
@@ -75,6 +88,7 @@ func Default() *Config {
 		},
 		TCP: &TCP{
 			Bind:         []string{":3101"},
+			TLSBind:      []string{":3105"},
 			Sndbuf:       4096,
 			Rcvbuf:       4096,
 			KeepAlive:    false,
@@ -86,7 +100,16 @@ func Default() *Config {
 			WriteBufSize: 8192,
 		},
 		Websocket: &Websocket{
-			Bind: []string{":3102"},
+			Bind:                    []string{":3102"},
+			CompressLevel:           1,
+			ServerNoContextTakeover: true,
+			ClientNoContextTakeover: true,
+		},
+		ProxyProtocol: &ProxyProtocol{
+			Timeout: xtime.Duration(time.Second * 5),
+		},
+		SSE: &SSE{
+			Bind: []string{":3104"},
 		},
 		Protocol: &Protocol{
 			Timer:            32,
@@ -94,6 +117,16 @@ func Default() *Config {
 			CliProto:         5,
 			SvrProto:         10,
 			HandshakeTimeout: xtime.Duration(time.Second * 5),
+			WriteTimeout:     xtime.Duration(time.Second * 5),
+			FlushTimeout:     xtime.Duration(time.Second * 5),
+			RefreshAhead:     xtime.Duration(time.Minute),
+			SlowPolicy:       "drop-newest",
+			OverflowSize:     64,
+		},
+		Drain: &Drain{
+			Window: xtime.Duration(time.Second * 30),
+			Grace:  xtime.Duration(time.Second * 5),
+			Batch:  100,
 		},
 		Bucket: &Bucket{
 			Size:          32,
@@ -101,22 +134,41 @@ func Default() *Config {
 			Room:          1024,
 			RoutineAmount: 32,
 			RoutineSize:   1024,
+			MaxRooms:      8,
+			MaxTopics:     64,
+		},
+		Admin: &Admin{
+			Network:     "tcp",
+			Addr:        "127.0.0.1:3113",
+			ReadTimeout: xtime.Duration(time.Second),
+		},
+		Debugger: &Debugger{
+			Events:    1024,
+			Conns:     1000,
+			Expire:    xtime.Duration(time.Minute * 10),
+			MaxExpire: xtime.Duration(time.Hour),
 		},
 	}
 }
 
 // Config is comet config.
 type Config struct {
-	Debug     bool
-	Env       *Env
-	Discovery *naming.Config
-	TCP       *TCP
-	Websocket *Websocket
-	Protocol  *Protocol
-	Bucket    *Bucket
-	RPCClient *RPCClient
-	RPCServer *RPCServer
-	Whitelist *Whitelist
+	Debug         bool
+	Env           *Env
+	Discovery     *naming.Config
+	TCP           *TCP
+	Websocket     *Websocket
+	SSE           *SSE
+	Protocol      *Protocol
+	ProxyProtocol *ProxyProtocol
+	Drain         *Drain
+	Bucket        *Bucket
+	RPCClient     *RPCClient
+	RPCServer     *RPCServer
+	HTTPServer    *HTTPServer
+	Admin         *Admin
+	Debugger      *Debugger
+	Trace         *trace.Config
 }
 
 // Env is env config.
@@ -148,9 +200,30 @@ type RPCServer struct {
 	KeepAliveTimeout  xtime.Duration
 }
 
+// HTTPServer is http server config of metrics.
+type HTTPServer struct {
+	Network      string
+	Addr         string
+	ReadTimeout  xtime.Duration
+	WriteTimeout xtime.Duration
+}
+
+// Admin is the admin api server config, no write timeout for the streamed
+// debug events.
+type Admin struct {
+	Network     string
+	Addr        string // loopback by default
+	ReadTimeout xtime.Duration
+	Token       string // bearer token required if not empty
+}
+
 // TCP is tcp config.
 type TCP struct {
 	Bind         []string
+	TLSOpen      bool
+	TLSBind      []string
+	CertFile     string
+	PrivateFile  string
 	Sndbuf       int
 	Rcvbuf       int
 	KeepAlive    bool
@@ -169,6 +242,25 @@ type Websocket struct {
 	TLSBind     []string
 	CertFile    string
 	PrivateFile string
+	// permessage-deflate
+	Compress                bool
+	CompressLevel           int
+	ServerNoContextTakeover bool // a compressor per connection if false, about 1MB each
+	ClientNoContextTakeover bool // a 32K window per connection if false
+	MaxInflateSize          int  // max inflated message size, zero the max proto pack size
+}
+
+// ProxyProtocol is PROXY protocol config for tcp and websocket listeners.
+type ProxyProtocol struct {
+	Open    bool
+	Trusted []string // trusted source cidrs, required if open
+	Timeout xtime.Duration
+}
+
+// SSE is server-sent events config.
+type SSE struct {
+	Open bool
+	Bind []string
 }
 
 // Protocol is protocol config.
@@ -178,6 +270,26 @@ type Protocol struct {
 	SvrProto         int
 	CliProto         int
 	HandshakeTimeout xtime.Duration
+	WriteTimeout     xtime.Duration // per write deadline of dispatch, zero disabled
+	FlushTimeout     xtime.Duration // per flush deadline of dispatch, zero disabled
+	RefreshAhead     xtime.Duration // ask the client to refresh the token ahead of the session expiry
+	// slow-consumer policy when the SvrProto signal is full:
+	// drop-newest, drop-oldest, disconnect or overflow.
+	SlowPolicy   string
+	OverflowSize int // overflow buffer size per channel
+	// stamp the per-connection seq on the server pushes instead of the seq
+	// of the producer, the seqs of the dropped pushes are skipped. The inbox
+	// seqs are overwritten so sync is refused.
+	PushSeq bool
+	PushGap bool // notify the client the dropped pushes by OpPushGap
+}
+
+// Drain is graceful shutdown config.
+type Drain struct {
+	Window    xtime.Duration // spread the disconnect replies over the window
+	Grace     xtime.Duration // wait the connections close after the window
+	Reconnect string         // reconnect hint, the body of OpDisconnectReply
+	Batch     int            // batch size of logic disconnects
 }
 
 // Bucket is bucket config.
@@ -187,10 +299,14 @@ type Bucket struct {
 	Room          int
 	RoutineAmount uint64
 	RoutineSize   int
+	MaxRooms      int // max joined rooms per channel, zero unlimited
+	MaxTopics     int // max subscribed topics per channel, zero unlimited
 }
 
-// Whitelist is white list config.
-type Whitelist struct {
-	Whitelist []int64
-	WhiteLog  string
+// Debugger is debug tracing config.
+type Debugger struct {
+	Events    int            // max kept events per traced connection
+	Conns     int            // max traced connections
+	Expire    xtime.Duration // default session expire
+	MaxExpire xtime.Duration
 }
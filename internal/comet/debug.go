@@ -0,0 +1,383 @@
+package comet
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/comet/conf"
+	log "github.com/golang/glog"
+)
+
+// debug event types.
+const (
+	DebugAuth       = "auth"
+	DebugRead       = "read"
+	DebugReply      = "reply"
+	DebugPush       = "push"
+	DebugFlush      = "flush"
+	DebugDisconnect = "disconnect"
+)
+
+// debug session target kinds.
+const (
+	DebugMid = "mid"
+	DebugKey = "key"
+	DebugIP  = "ip"
+)
+
+// DebugEvent is a trace event of a traced connection.
+type DebugEvent struct {
+	Seq  uint64 `json:"seq"`
+	Time int64  `json:"time"` // unix nano
+	Type string `json:"type"`
+	Msg  string `json:"msg"`
+}
+
+// DebugSession is a trace session of the connections of a mid, key or ip.
+type DebugSession struct {
+	Kind   string `json:"kind"`
+	Target string `json:"target"`
+	Expire int64  `json:"expire"` // unix time
+}
+
+// debugLog is the bounded event ring of a traced connection.
+type debugLog struct {
+	key    string
+	mid    int64
+	ip     string
+	ch     *Channel // the last traced channel of the key, nil if disconnected
+	mu     sync.Mutex
+	events []DebugEvent
+	seq    uint64        // seq of the last event
+	notify chan struct{} // closed on a new event
+	done   chan struct{} // closed if the log is removed
+}
+
+func newDebugLog(ch *Channel, size int) *debugLog {
+	return &debugLog{
+		ch:     ch,
+		key:    ch.Key,
+		mid:    ch.Mid,
+		ip:     ch.IP,
+		events: make([]DebugEvent, 0, size),
+		notify: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (l *debugLog) add(typ, msg string) {
+	l.mu.Lock()
+	l.seq++
+	e := DebugEvent{Seq: l.seq, Time: time.Now().UnixNano(), Type: typ, Msg: msg}
+	if len(l.events) < cap(l.events) {
+		l.events = append(l.events, e)
+	} else {
+		l.events[(l.seq-1)%uint64(cap(l.events))] = e
+	}
+	close(l.notify)
+	l.notify = make(chan struct{})
+	l.mu.Unlock()
+}
+
+// since get the kept events after the seq, and a channel closed on the next
+// event.
+func (l *debugLog) since(seq uint64) (events []DebugEvent, notify chan struct{}) {
+	l.mu.Lock()
+	if first := l.seq - uint64(len(l.events)); seq < first {
+		seq = first
+	}
+	for ; seq < l.seq; seq++ {
+		events = append(events, l.events[seq%uint64(cap(l.events))])
+	}
+	notify = l.notify
+	l.mu.Unlock()
+	return
+}
+
+// Debugger traces the connections of the mids, keys or ips at runtime, the
+// events of a traced connection are kept in a bounded ring until the
+// sessions expire or are disabled. The ring is cached on the channel at auth
+// and on Enable, so the events are recorded without the mutex.
+type Debugger struct {
+	c        *conf.Debugger
+	channels func(kind, target string) []*Channel // live channels of a session
+	sessions int32                                // number of the sessions, zero if nothing traced
+	mutex    sync.RWMutex
+	mids     map[int64]time.Time // session expire time
+	keys     map[string]time.Time
+	ips      map[string]time.Time
+	logs     map[string]*debugLog // traced connections by key
+}
+
+// NewDebugger new a debugger and start the session expiry, channels get the
+// live channels to trace on Enable.
+func NewDebugger(c *conf.Debugger, channels func(kind, target string) []*Channel) (d *Debugger) {
+	if c.Events <= 0 {
+		c.Events = 1024
+	}
+	d = &Debugger{
+		c:        c,
+		channels: channels,
+		mids:     make(map[int64]time.Time),
+		keys:     make(map[string]time.Time),
+		ips:      make(map[string]time.Time),
+		logs:     make(map[string]*debugLog),
+	}
+	go d.expireproc()
+	return
+}
+
+// Enable start or renew a session of the mid, key or ip, the default expire
+// is used if zero.
+func (d *Debugger) Enable(kind, target string, expire time.Duration) (err error) {
+	var mid int64
+	if kind == DebugMid {
+		if mid, err = strconv.ParseInt(target, 10, 64); err != nil {
+			return
+		}
+	}
+	if expire <= 0 {
+		expire = time.Duration(d.c.Expire)
+	}
+	if max := time.Duration(d.c.MaxExpire); max > 0 && expire > max {
+		expire = max
+	}
+	var (
+		now      = time.Now()
+		deadline = now.Add(expire)
+		chs      []*Channel
+	)
+	if d.channels != nil {
+		chs = d.channels(kind, target)
+	}
+	d.mutex.Lock()
+	switch kind {
+	case DebugMid:
+		d.mids[mid] = deadline
+	case DebugKey:
+		d.keys[target] = deadline
+	case DebugIP:
+		d.ips[target] = deadline
+	default:
+		err = fmt.Errorf("unknown debug kind: %s", kind)
+	}
+	d.update()
+	for _, ch := range chs {
+		d.attach(ch, now)
+	}
+	d.mutex.Unlock()
+	return
+}
+
+// Attach trace the channel if any session matches, called after auth.
+func (d *Debugger) Attach(ch *Channel) {
+	if atomic.LoadInt32(&d.sessions) == 0 {
+		return
+	}
+	d.mutex.Lock()
+	d.attach(ch, time.Now())
+	d.mutex.Unlock()
+}
+
+// attach cache the event log on the channel if any session matches, must be
+// called with the mutex held.
+func (d *Debugger) attach(ch *Channel, now time.Time) {
+	if !d.match(ch.Mid, ch.Key, ch.IP, now) {
+		return
+	}
+	l := d.logs[ch.Key]
+	if l == nil {
+		if len(d.logs) >= d.c.Conns && !d.evict() {
+			log.Errorf("key: %s mid: %d debug traced connections full", ch.Key, ch.Mid)
+			return
+		}
+		l = newDebugLog(ch, d.c.Events)
+		d.logs[ch.Key] = l
+	}
+	l.ch = ch
+	ch.setDebugLog(l)
+}
+
+// Detach mark the event log of the channel disconnected, called after the
+// connection is gone.
+func (d *Debugger) Detach(ch *Channel) {
+	l := ch.debugLog()
+	if l == nil {
+		return
+	}
+	d.mutex.Lock()
+	if l.ch == ch {
+		l.ch = nil
+	}
+	d.mutex.Unlock()
+}
+
+// evict delete a log of the disconnected connections to trace a live one,
+// must be called with the mutex held.
+func (d *Debugger) evict() bool {
+	for key, l := range d.logs {
+		if l.ch == nil {
+			delete(d.logs, key)
+			close(l.done)
+			return true
+		}
+	}
+	return false
+}
+
+// Disable stop the session of the mid, key or ip.
+func (d *Debugger) Disable(kind, target string) (err error) {
+	d.mutex.Lock()
+	switch kind {
+	case DebugMid:
+		var mid int64
+		if mid, err = strconv.ParseInt(target, 10, 64); err == nil {
+			delete(d.mids, mid)
+		}
+	case DebugKey:
+		delete(d.keys, target)
+	case DebugIP:
+		delete(d.ips, target)
+	default:
+		err = fmt.Errorf("unknown debug kind: %s", kind)
+	}
+	d.update()
+	d.mutex.Unlock()
+	return
+}
+
+// Sessions get the sessions and the keys of the traced connections.
+func (d *Debugger) Sessions() (sessions []*DebugSession, keys []string) {
+	sessions = []*DebugSession{}
+	keys = []string{}
+	d.mutex.RLock()
+	for mid, expire := range d.mids {
+		sessions = append(sessions, &DebugSession{Kind: DebugMid, Target: strconv.FormatInt(mid, 10), Expire: expire.Unix()})
+	}
+	for key, expire := range d.keys {
+		sessions = append(sessions, &DebugSession{Kind: DebugKey, Target: key, Expire: expire.Unix()})
+	}
+	for ip, expire := range d.ips {
+		sessions = append(sessions, &DebugSession{Kind: DebugIP, Target: ip, Expire: expire.Unix()})
+	}
+	for key := range d.logs {
+		keys = append(keys, key)
+	}
+	d.mutex.RUnlock()
+	return
+}
+
+// Events get the kept events after the seq of the traced connection, notify
+// is closed on the next event and done is closed if the connection is no
+// longer traced, ok is false if not traced.
+func (d *Debugger) Events(key string, seq uint64) (events []DebugEvent, notify, done chan struct{}, ok bool) {
+	var l *debugLog
+	d.mutex.RLock()
+	l, ok = d.logs[key]
+	d.mutex.RUnlock()
+	if !ok {
+		return
+	}
+	events, notify = l.since(seq)
+	done = l.done
+	return
+}
+
+// Printf record an event of the channel if traced.
+func (d *Debugger) Printf(ch *Channel, typ, format string, v ...interface{}) {
+	if l := ch.debugLog(); l != nil {
+		l.add(typ, fmt.Sprintf(format, v...))
+	}
+}
+
+// match check if any unexpired session matches, must be called with the
+// mutex held.
+func (d *Debugger) match(mid int64, key, ip string, now time.Time) bool {
+	if expire, ok := d.mids[mid]; ok && mid > 0 && now.Before(expire) {
+		return true
+	}
+	if expire, ok := d.keys[key]; ok && now.Before(expire) {
+		return true
+	}
+	if expire, ok := d.ips[ip]; ok && now.Before(expire) {
+		return true
+	}
+	return false
+}
+
+// update delete the expired sessions and the logs no longer traced, must be
+// called with the mutex held.
+func (d *Debugger) update() {
+	now := time.Now()
+	for mid, expire := range d.mids {
+		if !now.Before(expire) {
+			delete(d.mids, mid)
+		}
+	}
+	for key, expire := range d.keys {
+		if !now.Before(expire) {
+			delete(d.keys, key)
+		}
+	}
+	for ip, expire := range d.ips {
+		if !now.Before(expire) {
+			delete(d.ips, ip)
+		}
+	}
+	for key, l := range d.logs {
+		if !d.match(l.mid, l.key, l.ip, now) {
+			delete(d.logs, key)
+			if l.ch != nil {
+				l.ch.setDebugLog(nil)
+			}
+			close(l.done)
+		}
+	}
+	atomic.StoreInt32(&d.sessions, int32(len(d.mids)+len(d.keys)+len(d.ips)))
+}
+
+// expireproc expire the sessions.
+func (d *Debugger) expireproc() {
+	for {
+		time.Sleep(time.Second)
+		if atomic.LoadInt32(&d.sessions) == 0 {
+			continue
+		}
+		d.mutex.Lock()
+		d.update()
+		d.mutex.Unlock()
+	}
+}
+
+// debugChannels get the live channels of the mid, key or ip.
+func (s *Server) debugChannels(kind, target string) (chs []*Channel) {
+	switch kind {
+	case DebugMid:
+		if mid, err := strconv.ParseInt(target, 10, 64); err == nil {
+			chs = s.MidChannels(mid)
+		}
+	case DebugKey:
+		if ch := s.Bucket(target).Channel(target); ch != nil {
+			chs = append(chs, ch)
+		}
+	case DebugIP:
+		for _, b := range s.buckets {
+			chs = append(chs, b.IPChannels(target)...)
+		}
+	}
+	return
+}
+
+// debugLog get the cached event log, nil if not traced.
+func (c *Channel) debugLog() (l *debugLog) {
+	l, _ = c.debug.Load().(*debugLog)
+	return
+}
+
+// setDebugLog cache the event log, nil if no longer traced.
+func (c *Channel) setDebugLog(l *debugLog) {
+	c.debug.Store(l)
+}
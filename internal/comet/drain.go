@@ -0,0 +1,128 @@
+package comet
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/Terry-Mao/goim/api/protocol"
+	log "github.com/golang/glog"
+)
+
+// addListener add a listener closed when draining.
+func (s *Server) addListener(lis io.Closer) {
+	s.lisLock.Lock()
+	s.listeners = append(s.listeners, lis)
+	s.lisLock.Unlock()
+}
+
+// Draining reports whether the server is draining.
+func (s *Server) Draining() bool {
+	return atomic.LoadInt32(&s.draining) == 1
+}
+
+const _drainTick = 100 * time.Millisecond
+
+// drain stops accepting, sends every channel an OpDisconnectReply in batches
+// per tick spread over the drain window, then waits the connections close in
+// the grace.
+func (s *Server) drain() {
+	if !atomic.CompareAndSwapInt32(&s.draining, 0, 1) {
+		return
+	}
+	s.lisLock.Lock()
+	for _, lis := range s.listeners {
+		if err := lis.Close(); err != nil {
+			log.Errorf("listener.Close() error(%v)", err)
+		}
+	}
+	s.listeners = nil
+	s.lisLock.Unlock()
+	var (
+		chs    = s.channels()
+		window = time.Duration(s.c.Drain.Window)
+		ticks  = int(window / _drainTick)
+		p      = &protocol.Proto{Ver: 1, Op: protocol.OpDisconnectReply, Body: []byte(s.c.Drain.Reconnect)}
+	)
+	log.Infof("comet draining %d connections in %s", len(chs), window)
+	if ticks < 1 {
+		ticks = 1
+	}
+	batch := (len(chs) + ticks - 1) / ticks
+	ticker := time.NewTicker(_drainTick)
+	for i := 0; i < len(chs); i += batch {
+		end := i + batch
+		if end > len(chs) {
+			end = len(chs)
+		}
+		for _, ch := range chs[i:end] {
+			if err := ch.Push(p); err != nil {
+				log.Errorf("key: %s drain push error(%v)", ch.Key, err)
+			}
+		}
+		if end < len(chs) {
+			<-ticker.C
+		}
+	}
+	ticker.Stop()
+	// wait the connections close, or disconnect the rest from logic
+	for deadline := time.Now().Add(time.Duration(s.c.Drain.Grace)); time.Now().Before(deadline); time.Sleep(time.Millisecond * 100) {
+		if chs = s.channels(); len(chs) == 0 {
+			break
+		}
+	}
+	for _, ch := range s.channels() {
+		s.drainDisconnect(ch.Mid, ch.Key)
+	}
+	s.flushDisconnects()
+	log.Infof("comet drained")
+}
+
+func (s *Server) channels() (chs []*Channel) {
+	for _, b := range s.buckets {
+		chs = append(chs, b.Channels()...)
+	}
+	return
+}
+
+// drainDisconnect add a disconnect to the batch, flush if the batch is full,
+// it reports false if the drain is finished and the disconnect must be sent
+// alone.
+func (s *Server) drainDisconnect(mid int64, key string) bool {
+	var conns map[string]int64
+	s.drainLock.Lock()
+	if s.drained {
+		s.drainLock.Unlock()
+		return false
+	}
+	s.drainConns[key] = mid
+	if len(s.drainConns) >= s.c.Drain.Batch {
+		conns = s.drainConns
+		s.drainConns = make(map[string]int64, s.c.Drain.Batch)
+	}
+	s.drainLock.Unlock()
+	if conns != nil {
+		s.disconnects(conns)
+	}
+	return true
+}
+
+// flushDisconnects send the rest of the batch and finish the drain, the
+// later disconnects are not batched.
+func (s *Server) flushDisconnects() {
+	s.drainLock.Lock()
+	conns := s.drainConns
+	s.drainConns = make(map[string]int64)
+	s.drained = true
+	s.drainLock.Unlock()
+	if len(conns) > 0 {
+		s.disconnects(conns)
+	}
+}
+
+func (s *Server) disconnects(conns map[string]int64) {
+	if err := s.Disconnects(context.Background(), conns); err != nil {
+		log.Errorf("s.Disconnects(%d) error(%v)", len(conns), err)
+	}
+}
@@ -25,9 +25,17 @@ var (
 	// bucket
 	ErrBroadCastArg     = errors.New("rpc broadcast arg error")
 	ErrBroadCastRoomArg = errors.New("rpc broadcast  room arg error")
+	ErrKickArg          = errors.New("rpc kick arg error")
+	ErrPushTopicArg     = errors.New("rpc push topic arg error")
 
 	// room
 	ErrRoomDroped = errors.New("room droped")
+	ErrRoomsFull  = errors.New("joined rooms full")
+	ErrRoomID     = errors.New("room id empty")
+	// topic
+	ErrTopicsFull = errors.New("subscribed topics full")
 	// rpc
 	ErrLogic = errors.New("logic rpc is not available")
+	// tls
+	ErrNoCertificate = errors.New("no tls certificate")
 )
@@ -0,0 +1,94 @@
+package comet
+
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+
+	"github.com/Terry-Mao/goim/api/protocol"
+	xtime "github.com/Terry-Mao/goim/pkg/time"
+	log "github.com/golang/glog"
+)
+
+var (
+	refreshProto = &protocol.Proto{Ver: 1, Op: protocol.OpAuthRefresh}
+	expiredProto = &protocol.Proto{Ver: 1, Op: protocol.OpDisconnectReply}
+)
+
+func init() {
+	expiredProto.Body, _ = json.Marshal(&ack{Code: ackUnauthorized, Msg: "token expired"})
+}
+
+// expiry schedules the refresh request and the expiry of an authed session
+// on the connection timer, set and del must be called by the reader goroutine.
+type expiry struct {
+	ch     *Channel
+	tr     *xtime.Timer
+	ahead  time.Duration
+	expire int64 // unix seconds, zero never expires
+	rtd    *xtime.TimerData
+	etd    *xtime.TimerData
+	closed bool
+}
+
+// watchExpiry watch the session expiry of the channel.
+func (s *Server) watchExpiry(tr *xtime.Timer, ch *Channel, expire int64) {
+	ch.expiry = &expiry{ch: ch, tr: tr, ahead: time.Duration(s.c.Protocol.RefreshAhead)}
+	ch.expiry.set(expire)
+}
+
+// set reset the session expiry.
+func (e *expiry) set(expire int64) {
+	if e.closed {
+		return
+	}
+	atomic.StoreInt64(&e.expire, expire)
+	if expire == 0 {
+		e.del()
+		return
+	}
+	d := time.Until(time.Unix(expire, 0))
+	if e.etd == nil {
+		e.rtd = e.tr.Add(d-e.ahead, e.refresh)
+		e.etd = e.tr.Add(d, e.expired)
+		e.rtd.Key = e.ch.Key
+		e.etd.Key = e.ch.Key
+		return
+	}
+	e.tr.Set(e.rtd, d-e.ahead)
+	e.tr.Set(e.etd, d)
+}
+
+// del stop watching the expiry.
+func (e *expiry) del() {
+	if e.etd != nil {
+		e.tr.Del(e.rtd)
+		e.tr.Del(e.etd)
+		e.rtd, e.etd = nil, nil
+	}
+}
+
+// stop stop watching the expiry when the connection is gone.
+func (e *expiry) stop() {
+	e.closed = true
+	e.del()
+}
+
+// refresh ask the client to refresh the token by OpAuth.
+func (e *expiry) refresh() {
+	if err := e.ch.Push(refreshProto); err != nil {
+		log.Errorf("key: %s push auth refresh error(%v)", e.ch.Key, err)
+	}
+}
+
+// expired disconnect the client with a reason code, it runs on the timer
+// goroutine shared by the round, so the conn is never closed on it.
+func (e *expiry) expired() {
+	if expire := atomic.LoadInt64(&e.expire); expire == 0 || time.Now().Unix() < expire {
+		// refreshed
+		return
+	}
+	log.Warningf("key: %s mid: %d session expired", e.ch.Key, e.ch.Mid)
+	// kick closes asynchronously if the signal is full
+	kick(e.ch, expiredProto)
+}
@@ -6,9 +6,11 @@ import (
 	"time"
 
 	pb "github.com/Terry-Mao/goim/api/comet"
+	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/comet"
 	"github.com/Terry-Mao/goim/internal/comet/conf"
 	"github.com/Terry-Mao/goim/internal/comet/errors"
+	"github.com/Terry-Mao/goim/pkg/trace"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/keepalive"
@@ -23,7 +25,7 @@ func New(c *conf.RPCServer, s *comet.Server) *grpc.Server {
 		Timeout:               time.Duration(c.KeepAliveTimeout),
 		MaxConnectionAge:      time.Duration(c.MaxLifeTime),
 	})
-	srv := grpc.NewServer(keepParams)
+	srv := grpc.NewServer(keepParams, grpc.UnaryInterceptor(comet.RPCMetrics))
 	pb.RegisterCometServer(srv, &server{s})
 	lis, err := net.Listen(c.Network, c.Addr)
 	if err != nil {
@@ -43,11 +45,24 @@ type server struct {
 
 var _ pb.CometServer = &server{}
 
+// startPush start the server span of the push if the proto is traced, the
+// dispatch spans of the proto are children of it.
+func startPush(method string, p *protocol.Proto) (span *trace.Span) {
+	if span = trace.Start("comet."+method, trace.KindServer, p.Trace); span != nil {
+		p.Trace = span.Traceparent()
+	}
+	return
+}
+
 // PushMsg push a message to specified sub keys.
 func (s *server) PushMsg(ctx context.Context, req *pb.PushMsgReq) (reply *pb.PushMsgReply, err error) {
 	if len(req.Keys) == 0 || req.Proto == nil {
 		return nil, errors.ErrPushMsgArg
 	}
+	span := startPush("PushMsg", req.Proto)
+	defer func() {
+		span.Finish(err)
+	}()
 	for _, key := range req.Keys {
 		bucket := s.srv.Bucket(key)
 		if bucket == nil {
@@ -70,6 +85,7 @@ func (s *server) Broadcast(ctx context.Context, req *pb.BroadcastReq) (*pb.Broad
 	if req.Proto == nil {
 		return nil, errors.ErrBroadCastArg
 	}
+	defer startPush("Broadcast", req.Proto).Finish(nil)
 	// TODO use broadcast queue
 	go func() {
 		for _, bucket := range s.srv.Buckets() {
@@ -88,6 +104,7 @@ func (s *server) BroadcastRoom(ctx context.Context, req *pb.BroadcastRoomReq) (*
 	if req.Proto == nil || req.RoomID == "" {
 		return nil, errors.ErrBroadCastRoomArg
 	}
+	defer startPush("BroadcastRoom", req.Proto).Finish(nil)
 	for _, bucket := range s.srv.Buckets() {
 		bucket.BroadcastRoom(req)
 	}
@@ -106,3 +123,37 @@ func (s *server) Rooms(ctx context.Context, req *pb.RoomsReq) (*pb.RoomsReply, e
 	}
 	return &pb.RoomsReply{Rooms: roomIds}, nil
 }
+
+// KickKeys disconnect the specified keys.
+func (s *server) KickKeys(ctx context.Context, req *pb.KickKeysReq) (*pb.KickReply, error) {
+	if len(req.Keys) == 0 {
+		return nil, errors.ErrKickArg
+	}
+	return &pb.KickReply{Count: int32(s.srv.KickKeys(req.Keys, req.Reason))}, nil
+}
+
+// KickRoom disconnect all in the specified room.
+func (s *server) KickRoom(ctx context.Context, req *pb.KickRoomReq) (*pb.KickReply, error) {
+	if req.RoomID == "" {
+		return nil, errors.ErrKickArg
+	}
+	return &pb.KickReply{Count: int32(s.srv.KickRoom(req.RoomID, req.Reason))}, nil
+}
+
+// KickIP disconnect all of the specified ip.
+func (s *server) KickIP(ctx context.Context, req *pb.KickIPReq) (*pb.KickReply, error) {
+	if req.Ip == "" {
+		return nil, errors.ErrKickArg
+	}
+	return &pb.KickReply{Count: int32(s.srv.KickIP(req.Ip, req.Reason))}, nil
+}
+
+// PushTopic push msg to the subscribers of the topic.
+func (s *server) PushTopic(ctx context.Context, req *pb.PushTopicReq) (*pb.PushTopicReply, error) {
+	if req.Proto == nil || req.Topic == "" {
+		return nil, errors.ErrPushTopicArg
+	}
+	defer startPush("PushTopic", req.Proto).Finish(nil)
+	s.srv.PushTopic(req.Topic, req.Proto)
+	return &pb.PushTopicReply{}, nil
+}
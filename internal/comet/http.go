@@ -0,0 +1,39 @@
+package comet
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/comet/conf"
+	log "github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// InitHTTP listen the http server and serve the metrics at /metrics.
+func InitHTTP(server *Server, c *conf.HTTPServer) (err error) {
+	var listener net.Listener
+	if err = prometheus.Register(&bucketCollector{srv: server}); err != nil {
+		log.Errorf("prometheus.Register() error(%v)", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	if listener, err = net.Listen(c.Network, c.Addr); err != nil {
+		log.Errorf("net.Listen(%s, %s) error(%v)", c.Network, c.Addr, err)
+		return
+	}
+	log.Infof("start http listen: %s", c.Addr)
+	srv := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  time.Duration(c.ReadTimeout),
+		WriteTimeout: time.Duration(c.WriteTimeout),
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.Errorf("http serve(%s) error(%v)", c.Addr, err)
+		}
+	}()
+	return
+}
@@ -0,0 +1,75 @@
+package comet
+
+import (
+	"encoding/json"
+	"net"
+
+	"github.com/Terry-Mao/goim/api/protocol"
+	log "github.com/golang/glog"
+)
+
+// kick push the OpDisconnectReply to the channel, the dispatcher closes the
+// connection after writing it, or close at once if the signal is full.
+func kick(ch *Channel, p *protocol.Proto) {
+	if err := ch.Push(p); err != nil && ch.conn != nil {
+		// closing a tls conn may block, never on the caller
+		go ch.conn.Close()
+	}
+}
+
+// kickProto new a OpDisconnectReply proto with the reason.
+func kickProto(reason string) *protocol.Proto {
+	body, _ := json.Marshal(&ack{Code: ackKicked, Msg: reason})
+	return &protocol.Proto{Ver: 1, Op: protocol.OpDisconnectReply, Body: body}
+}
+
+// KickKeys disconnect the channels of the keys.
+func (s *Server) KickKeys(keys []string, reason string) (count int) {
+	p := kickProto(reason)
+	for _, key := range keys {
+		if ch := s.Bucket(key).Channel(key); ch != nil {
+			kick(ch, p)
+			count++
+		}
+	}
+	log.Infof("kick keys:%v reason:%s count:%d", keys, reason, count)
+	return
+}
+
+// KickRoom disconnect all channels in the room.
+func (s *Server) KickRoom(rid, reason string) (count int) {
+	p := kickProto(reason)
+	for _, b := range s.buckets {
+		for _, ch := range b.RoomChannels(rid) {
+			kick(ch, p)
+			count++
+		}
+	}
+	log.Infof("kick room:%s reason:%s count:%d", rid, reason, count)
+	return
+}
+
+// KickIP disconnect all channels of the ip or in the cidr.
+func (s *Server) KickIP(ip, reason string) (count int) {
+	p := kickProto(reason)
+	_, ipnet, _ := net.ParseCIDR(ip)
+	for _, b := range s.buckets {
+		ips := []string{ip}
+		if ipnet != nil {
+			ips = ips[:0]
+			for addr := range b.IPCount() {
+				if a := net.ParseIP(addr); a != nil && ipnet.Contains(a) {
+					ips = append(ips, addr)
+				}
+			}
+		}
+		for _, addr := range ips {
+			for _, ch := range b.IPChannels(addr) {
+				kick(ch, p)
+				count++
+			}
+		}
+	}
+	log.Infof("kick ip:%s reason:%s count:%d", ip, reason, count)
+	return
+}
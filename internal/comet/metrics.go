@@ -0,0 +1,161 @@
+package comet
+
+import (
+	"context"
+	"net"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	_namespace = "goim"
+	_subsystem = "comet"
+)
+
+var (
+	connections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "connections",
+		Help:      "Current connections per transport.",
+	}, []string{"transport"})
+	handshakes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "handshakes_total",
+		Help:      "Succeeded handshakes per transport.",
+	}, []string{"transport"})
+	handshakeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "handshake_failures_total",
+		Help:      "Failed handshakes per transport and step.",
+	}, []string{"transport", "step"})
+	heartbeatTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "heartbeat_timeouts_total",
+		Help:      "Connections closed by the heartbeat timeout per transport.",
+	}, []string{"transport"})
+	pushDrops = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "push_drops_total",
+		Help:      "Server pushes dropped by the full channels per op.",
+	}, []string{"op"})
+	ringFulls = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "ring_full_total",
+		Help:      "Client protos rejected by the full rings.",
+	})
+	transferBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "bytes_total",
+		Help:      "Bytes read and written per transport.",
+	}, []string{"transport", "direction"})
+	rpcRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "rpc_requests_total",
+		Help:      "Received grpc requests (pushes, broadcasts, room pushes etc.) per method and code.",
+	}, []string{"method", "code"})
+	logicDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: _namespace,
+		Subsystem: _subsystem,
+		Name:      "logic_rpc_duration_seconds",
+		Help:      "Latency of the logic rpc calls per method and code.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"method", "code"})
+
+	bucketChannelsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(_namespace, _subsystem, "bucket_channels"),
+		"Current channels per bucket.", []string{"bucket"}, nil)
+	bucketRoomsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(_namespace, _subsystem, "bucket_rooms"),
+		"Current rooms per bucket.", []string{"bucket"}, nil)
+)
+
+func init() {
+	prometheus.MustRegister(connections, handshakes, handshakeFailures, heartbeatTimeouts, pushDrops, ringFulls, transferBytes, rpcRequests, logicDuration)
+}
+
+// bucketCollector collects the channel and room counts of the buckets when
+// scraped.
+type bucketCollector struct {
+	srv *Server
+}
+
+func (c *bucketCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- bucketChannelsDesc
+	ch <- bucketRoomsDesc
+}
+
+func (c *bucketCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, b := range c.srv.Buckets() {
+		idx := strconv.Itoa(i)
+		ch <- prometheus.MustNewConstMetric(bucketChannelsDesc, prometheus.GaugeValue, float64(b.ChannelCount()), idx)
+		ch <- prometheus.MustNewConstMetric(bucketRoomsDesc, prometheus.GaugeValue, float64(b.RoomCount()), idx)
+	}
+}
+
+// RPCMetrics count the received grpc requests.
+func RPCMetrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	resp, err = handler(ctx, req)
+	rpcRequests.WithLabelValues(path.Base(info.FullMethod), status.Code(err).String()).Inc()
+	return
+}
+
+// logicMetrics observe the latency of the logic rpc calls.
+func logicMetrics(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	start := time.Now()
+	err = invoker(ctx, method, req, reply, cc, opts...)
+	logicDuration.WithLabelValues(path.Base(method), status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return
+}
+
+// meterConn counts the bytes read and written of the connection.
+type meterConn struct {
+	net.Conn
+	in  prometheus.Counter
+	out prometheus.Counter
+}
+
+func newMeterConn(conn net.Conn, transport string) net.Conn {
+	return &meterConn{
+		Conn: conn,
+		in:   transferBytes.WithLabelValues(transport, "in"),
+		out:  transferBytes.WithLabelValues(transport, "out"),
+	}
+}
+
+func (c *meterConn) Read(b []byte) (n int, err error) {
+	n, err = c.Conn.Read(b)
+	c.in.Add(float64(n))
+	return
+}
+
+func (c *meterConn) Write(b []byte) (n int, err error) {
+	n, err = c.Conn.Write(b)
+	c.out.Add(float64(n))
+	return
+}
+
+// meterListener counts the bytes of the accepted connections.
+type meterListener struct {
+	net.Listener
+	transport string
+}
+
+func (l *meterListener) Accept() (conn net.Conn, err error) {
+	if conn, err = l.Listener.Accept(); err != nil {
+		return
+	}
+	return newMeterConn(conn, l.transport), nil
+}
@@ -2,32 +2,228 @@ package comet
 
 import (
 	"context"
+	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/Terry-Mao/goim/api/logic"
 	"github.com/Terry-Mao/goim/api/protocol"
+	"github.com/Terry-Mao/goim/internal/comet/errors"
+	"github.com/Terry-Mao/goim/pkg/bytes"
 	"github.com/Terry-Mao/goim/pkg/strings"
 	log "github.com/golang/glog"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
 )
 
-// Connect connected a connection.
-func (s *Server) Connect(c context.Context, p *protocol.Proto, cookie string) (mid int64, key, rid string, accepts []int32, heartbeat time.Duration, err error) {
+const (
+	// ackBadRequest the ack code if the room id is empty, or sync with the
+	// push seq open.
+	ackBadRequest = int32(-400)
+	// ackUnauthorized the ack code if the auth token is not valid.
+	ackUnauthorized = int32(-401)
+	// ackForbidden the ack code if the room or ops are not authorized, or
+	// the connection is banned.
+	ackForbidden = int32(-403)
+	// ackLimited the ack code if the joined rooms or the topics are full.
+	ackLimited = int32(-429)
+	// ackKicked the disconnect code if kicked by the operators.
+	ackKicked = int32(-410)
+	// ackServerErr the ack code if logic is not available.
+	ackServerErr = int32(-500)
+)
+
+// ack is the status body of OpSendMsgReply, OpChangeRoomReply and
+// OpJoinRoomReply, the failed OpAuthReply and the denied OpSubReply and
+// OpSubTopicReply.
+type ack struct {
+	Code int32  `json:"code"`
+	Msg  string `json:"msg,omitempty"`
+}
+
+// syncAck is the status of OpSyncReply, the first packed proto of the body,
+// more if some messages are left for the next sync.
+type syncAck struct {
+	Code int32  `json:"code"`
+	Msg  string `json:"msg,omitempty"`
+	More bool   `json:"more,omitempty"`
+}
+
+// syncAckSize the max packed size of the status of a succeeded sync.
+var syncAckSize = func() int {
+	body, _ := json.Marshal(&syncAck{More: true})
+	return (&protocol.Proto{Body: body}).PackSize()
+}()
+
+// Connect connected a connection, the allowed rooms and ops of the token
+// are cached on the channel.
+func (s *Server) Connect(c context.Context, p *protocol.Proto, cookie string, ch *Channel) (mid int64, key, rid string, accepts []int32, heartbeat time.Duration, expire int64, err error) {
 	reply, err := s.rpcClient.Connect(c, &logic.ConnectReq{
+		Server:   s.serverID,
+		Cookie:   cookie,
+		Token:    p.Body,
+		ClientIP: ch.IP,
+	})
+	if err != nil {
+		return
+	}
+	ch.AllowRooms(reply.Rooms...)
+	ch.AllowRooms(reply.RoomID)
+	ch.AllowOps(reply.Ops...)
+	ch.AllowOps(reply.Accepts...)
+	ch.AllowTopics(reply.Topics...)
+	return reply.Mid, reply.Key, reply.RoomID, reply.Accepts, time.Duration(reply.Heartbeat), reply.Expire, nil
+}
+
+// Reauth re-authenticate a connection, returns the new session expiry.
+func (s *Server) Reauth(c context.Context, p *protocol.Proto, ch *Channel) (expire int64, err error) {
+	reply, err := s.rpcClient.Reauth(c, &logic.ReauthReq{
+		Mid:      ch.Mid,
+		Key:      ch.Key,
+		Server:   s.serverID,
+		Token:    p.Body,
+		ClientIP: ch.IP,
+	})
+	if err != nil {
+		return
+	}
+	return reply.Expire, nil
+}
+
+// Sync get the offline messages after the last seen seq, packed as the body
+// of OpSyncReply after the status, the messages beyond the max body size are
+// left for the next sync.
+func (s *Server) Sync(c context.Context, ch *Channel, seq int64) (body []byte, err error) {
+	reply, err := s.rpcClient.Sync(c, &logic.SyncReq{
+		Mid:    ch.Mid,
+		Key:    ch.Key,
 		Server: s.serverID,
-		Cookie: cookie,
-		Token:  p.Body,
+		Seq:    seq,
 	})
 	if err != nil {
 		return
 	}
-	return reply.Mid, reply.Key, reply.RoomID, reply.Accepts, time.Duration(reply.Heartbeat), nil
+	var (
+		a      = &syncAck{}
+		protos []*protocol.Proto
+		size   = syncAckSize
+		limit  = int(protocol.MaxBodySize)
+	)
+	for _, p := range reply.Protos {
+		if syncAckSize+p.PackSize() > limit {
+			// never fits in a body, clients reject it as pushed
+			log.Warningf("key: %s mid: %d sync seq: %d size: %d too large", ch.Key, ch.Mid, p.Seq, p.PackSize())
+			continue
+		}
+		if size += p.PackSize(); size > limit {
+			a.More = true
+			break
+		}
+		protos = append(protos, p)
+	}
+	return syncBody(a, protos), nil
+}
+
+// syncBody pack the status and the messages as the body of OpSyncReply.
+func syncBody(a *syncAck, protos []*protocol.Proto) []byte {
+	status := &protocol.Proto{Ver: 1, Op: protocol.OpSyncReply}
+	status.Body, _ = json.Marshal(a)
+	size := status.PackSize()
+	for _, p := range protos {
+		size += p.PackSize()
+	}
+	buf := bytes.NewWriterSize(size)
+	status.WriteTo(buf)
+	for _, p := range protos {
+		p.WriteTo(buf)
+	}
+	return buf.Buffer()
 }
 
-// Disconnect disconnected a connection.
+// Authorize check if the channel may enter the room or subscribe the ops and
+// the topics by the allow-lists, or else ask logic and cache the allowed.
+func (s *Server) Authorize(c context.Context, ch *Channel, room string, ops []int32, topics []string) (allow bool, err error) {
+	var (
+		denied       []int32
+		deniedTopics []string
+	)
+	for _, op := range ops {
+		if !ch.AllowedOp(op) {
+			denied = append(denied, op)
+		}
+	}
+	for _, topic := range topics {
+		if !ch.AllowedTopic(topic) {
+			deniedTopics = append(deniedTopics, topic)
+		}
+	}
+	if room != "" && ch.AllowedRoom(room) {
+		room = ""
+	}
+	if room == "" && len(denied) == 0 && len(deniedTopics) == 0 {
+		return true, nil
+	}
+	reply, err := s.rpcClient.Authorize(c, &logic.AuthorizeReq{
+		Mid:    ch.Mid,
+		Key:    ch.Key,
+		Server: s.serverID,
+		RoomID: room,
+		Ops:    denied,
+		Topics: deniedTopics,
+	})
+	if err != nil || !reply.Allow {
+		return
+	}
+	if room != "" {
+		ch.AllowRooms(room)
+	}
+	ch.AllowOps(denied...)
+	ch.AllowTopics(deniedTopics...)
+	return true, nil
+}
+
+// authFailed set the proto as the OpAuthReply of the failed Connect.
+func authFailed(p *protocol.Proto, err error) {
+	a := &ack{Code: ackServerErr, Msg: "server error"}
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		a = &ack{Code: ackUnauthorized, Msg: "unauthorized"}
+	case codes.PermissionDenied:
+		a = &ack{Code: ackForbidden, Msg: "banned"}
+	}
+	p.Op = protocol.OpAuthReply
+	p.Body, _ = json.Marshal(a)
+}
+
+// denied set the proto body as the failure status of a denied operation.
+func denied(p *protocol.Proto, err error) {
+	a := &ack{Code: ackForbidden, Msg: "forbidden"}
+	if err != nil {
+		a = &ack{Code: ackServerErr, Msg: "server error"}
+	}
+	p.Body, _ = json.Marshal(a)
+}
+
+// roomFailed set the proto body as the failure status of a room operation.
+func roomFailed(p *protocol.Proto, err error) {
+	a := &ack{Code: ackServerErr, Msg: "server error"}
+	switch err {
+	case errors.ErrRoomsFull:
+		a = &ack{Code: ackLimited, Msg: err.Error()}
+	case errors.ErrRoomID:
+		a = &ack{Code: ackBadRequest, Msg: err.Error()}
+	}
+	p.Body, _ = json.Marshal(a)
+}
+
+// Disconnect disconnected a connection, batched when draining.
 func (s *Server) Disconnect(c context.Context, mid int64, key string) (err error) {
+	if s.Draining() && s.drainDisconnect(mid, key) {
+		return
+	}
 	_, err = s.rpcClient.Disconnect(context.Background(), &logic.DisconnectReq{
 		Server: s.serverID,
 		Mid:    mid,
@@ -36,6 +232,15 @@ func (s *Server) Disconnect(c context.Context, mid int64, key string) (err error
 	return
 }
 
+// Disconnects disconnected a batch of connections, conns is key to mid.
+func (s *Server) Disconnects(c context.Context, conns map[string]int64) (err error) {
+	_, err = s.rpcClient.Disconnects(c, &logic.DisconnectsReq{
+		Server: s.serverID,
+		Conns:  conns,
+	})
+	return
+}
+
 // Heartbeat heartbeat a connection session.
 func (s *Server) Heartbeat(ctx context.Context, mid int64, key string) (err error) {
 	_, err = s.rpcClient.Heartbeat(ctx, &logic.HeartbeatReq{
@@ -58,36 +263,116 @@ func (s *Server) RenewOnline(ctx context.Context, serverID string, roomCount map
 	return reply.AllRoomCount, nil
 }
 
-// Receive receive a message.
-func (s *Server) Receive(ctx context.Context, mid int64, p *protocol.Proto) (err error) {
-	_, err = s.rpcClient.Receive(ctx, &logic.ReceiveReq{Mid: mid, Proto: p})
-	return
+// Receive receive a message, returns the ack status of logic.
+func (s *Server) Receive(ctx context.Context, ch *Channel, p *protocol.Proto) (code int32, msg string, err error) {
+	var room string
+	if ch.Room != nil {
+		room = ch.Room.ID
+	}
+	reply, err := s.rpcClient.Receive(ctx, &logic.ReceiveReq{
+		Mid:    ch.Mid,
+		Key:    ch.Key,
+		Server: s.serverID,
+		Room:   room,
+		Proto:  p,
+	})
+	if err != nil {
+		return
+	}
+	return reply.Code, reply.Msg, nil
 }
 
 // Operate operate.
 func (s *Server) Operate(ctx context.Context, p *protocol.Proto, ch *Channel, b *Bucket) error {
 	switch p.Op {
+	case protocol.OpAuth:
+		// re-authenticate to extend the session
+		if expire, err := s.Reauth(ctx, p, ch); err != nil {
+			log.Errorf("s.Reauth(%s) error(%v)", ch.Key, err)
+			authFailed(p, err)
+		} else {
+			ch.expiry.set(expire)
+			p.Op = protocol.OpAuthReply
+			p.Body = nil
+		}
 	case protocol.OpChangeRoom:
-		if err := b.ChangeRoom(string(p.Body), ch); err != nil {
+		room := string(p.Body)
+		if allow, err := s.Authorize(ctx, ch, room, nil, nil); err != nil || !allow {
+			log.Errorf("key: %s mid: %d change room(%s) denied error(%v)", ch.Key, ch.Mid, room, err)
+			denied(p, err)
+		} else if err = b.ChangeRoom(room, ch); err != nil {
 			log.Errorf("b.ChangeRoom(%s) error(%v)", p.Body, err)
+			roomFailed(p, err)
+		} else {
+			p.Body, _ = json.Marshal(&ack{})
 		}
 		p.Op = protocol.OpChangeRoomReply
+	case protocol.OpJoinRoom:
+		room := string(p.Body)
+		if room == "" {
+			roomFailed(p, errors.ErrRoomID)
+		} else if allow, err := s.Authorize(ctx, ch, room, nil, nil); err != nil || !allow {
+			log.Errorf("key: %s mid: %d join room(%s) denied error(%v)", ch.Key, ch.Mid, room, err)
+			denied(p, err)
+		} else if err = b.JoinRoom(room, ch); err != nil {
+			log.Errorf("b.JoinRoom(%s) error(%v)", p.Body, err)
+			roomFailed(p, err)
+		} else {
+			p.Body, _ = json.Marshal(&ack{})
+		}
+		p.Op = protocol.OpJoinRoomReply
+	case protocol.OpLeaveRoom:
+		b.LeaveRoom(string(p.Body), ch)
+		p.Op = protocol.OpLeaveRoomReply
 	case protocol.OpSub:
 		if ops, err := strings.SplitInt32s(string(p.Body), ","); err == nil {
-			ch.Watch(ops...)
+			if allow, err := s.Authorize(ctx, ch, "", ops, nil); err != nil || !allow {
+				log.Errorf("key: %s mid: %d sub(%v) denied error(%v)", ch.Key, ch.Mid, ops, err)
+				denied(p, err)
+			} else {
+				ch.Watch(ops...)
+			}
 		}
 		p.Op = protocol.OpSubReply
+	case protocol.OpSubTopic:
+		topics := splitTopics(p.Body)
+		if allow, err := s.Authorize(ctx, ch, "", nil, topics); err != nil || !allow {
+			log.Errorf("key: %s mid: %d sub topics(%v) denied error(%v)", ch.Key, ch.Mid, topics, err)
+			denied(p, err)
+		} else if err = b.Subscribe(ch, topics...); err != nil {
+			log.Errorf("b.Subscribe(%s) error(%v)", p.Body, err)
+			p.Body, _ = json.Marshal(&ack{Code: ackLimited, Msg: err.Error()})
+		}
+		p.Op = protocol.OpSubTopicReply
+	case protocol.OpUnsubTopic:
+		b.Unsubscribe(ch, splitTopics(p.Body)...)
+		p.Op = protocol.OpUnsubTopicReply
+	case protocol.OpSync:
+		seq, _ := strconv.ParseInt(string(p.Body), 10, 64)
+		if s.c.Protocol.PushSeq {
+			// the inbox seqs are overwritten by the push seqs
+			p.Body = syncBody(&syncAck{Code: ackBadRequest, Msg: "sync unsupported with push seq"}, nil)
+		} else if body, err := s.Sync(ctx, ch, seq); err != nil {
+			log.Errorf("s.Sync(%s,%d) error(%v)", ch.Key, seq, err)
+			p.Body = syncBody(&syncAck{Code: ackServerErr, Msg: "server error"}, nil)
+		} else {
+			p.Body = body
+		}
+		p.Op = protocol.OpSyncReply
 	case protocol.OpUnsub:
 		if ops, err := strings.SplitInt32s(string(p.Body), ","); err == nil {
 			ch.UnWatch(ops...)
 		}
 		p.Op = protocol.OpUnsubReply
 	default:
-		// TODO ack ok&failed
-		if err := s.Receive(ctx, ch.Mid, p); err != nil {
+		// ack with the client seq
+		code, msg, err := s.Receive(ctx, ch, p)
+		if err != nil {
 			log.Errorf("s.Report(%d) op:%d error(%v)", ch.Mid, p.Op, err)
+			code, msg = ackServerErr, "server error"
 		}
-		p.Body = nil
+		p.Op = protocol.OpSendMsgReply
+		p.Body, _ = json.Marshal(&ack{Code: code, Msg: msg})
 	}
 	return nil
 }
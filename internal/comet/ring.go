@@ -1,6 +1,8 @@
 package comet
 
 import (
+	"sync/atomic"
+
 	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/comet/conf"
 	"github.com/Terry-Mao/goim/internal/comet/errors"
@@ -47,38 +49,51 @@ func (r *Ring) init(num uint64) {
 
 // Get get a proto from ring.
 func (r *Ring) Get() (proto *protocol.Proto, err error) {
-	if r.rp == r.wp {
+	rp := atomic.LoadUint64(&r.rp)
+	if rp == atomic.LoadUint64(&r.wp) {
 		return nil, errors.ErrRingEmpty
 	}
-	proto = &r.data[r.rp&r.mask]
+	proto = &r.data[rp&r.mask]
 	return
 }
 
 // GetAdv incr read index.
 func (r *Ring) GetAdv() {
-	r.rp++
+	rp := atomic.AddUint64(&r.rp, 1)
 	if conf.Conf.Debug {
-		log.Infof("ring rp: %d, idx: %d", r.rp, r.rp&r.mask)
+		log.Infof("ring rp: %d, idx: %d", rp, rp&r.mask)
 	}
 }
 
 // Set get a proto to write.
 func (r *Ring) Set() (proto *protocol.Proto, err error) {
-	if r.wp-r.rp >= r.num {
+	wp := atomic.LoadUint64(&r.wp)
+	if wp-atomic.LoadUint64(&r.rp) >= r.num {
+		ringFulls.Inc()
 		return nil, errors.ErrRingFull
 	}
-	proto = &r.data[r.wp&r.mask]
+	proto = &r.data[wp&r.mask]
 	return
 }
 
 // SetAdv incr write index.
 func (r *Ring) SetAdv() {
-	r.wp++
+	wp := atomic.AddUint64(&r.wp, 1)
 	if conf.Conf.Debug {
-		log.Infof("ring wp: %d, idx: %d", r.wp, r.wp&r.mask)
+		log.Infof("ring wp: %d, idx: %d", wp, wp&r.mask)
 	}
 }
 
+// Len get the count of the pending protos.
+func (r *Ring) Len() int {
+	return int(atomic.LoadUint64(&r.wp) - atomic.LoadUint64(&r.rp))
+}
+
+// Size get the size of the ring.
+func (r *Ring) Size() int {
+	return int(r.num)
+}
+
 // Reset reset ring.
 func (r *Ring) Reset() {
 	r.rp = 0
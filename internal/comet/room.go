@@ -7,11 +7,20 @@ import (
 	"github.com/Terry-Mao/goim/internal/comet/errors"
 )
 
+// member is a channel node in the room linked list, a channel may be the
+// member of many rooms.
+type member struct {
+	ch   *Channel
+	room *Room
+	next *member
+	prev *member
+}
+
 // Room is a room and store channel room info.
 type Room struct {
 	ID        string
 	rLock     sync.RWMutex
-	next      *Channel
+	next      *member
 	drop      bool
 	Online    int32 // dirty read is ok
 	AllOnline int32
@@ -27,16 +36,15 @@ func NewRoom(id string) (r *Room) {
 	return
 }
 
-// Put put channel into the room.
-func (r *Room) Put(ch *Channel) (err error) {
+// Put put channel into the room, returns the member node.
+func (r *Room) Put(ch *Channel) (m *member, err error) {
 	r.rLock.Lock()
 	if !r.drop {
+		m = &member{ch: ch, room: r, next: r.next}
 		if r.next != nil {
-			r.next.Prev = ch
+			r.next.prev = m
 		}
-		ch.Next = r.next
-		ch.Prev = nil
-		r.next = ch // insert to header
+		r.next = m // insert to header
 		r.Online++
 	} else {
 		err = errors.ErrRoomDroped
@@ -45,21 +53,21 @@ func (r *Room) Put(ch *Channel) (err error) {
 	return
 }
 
-// Del delete channel from the room.
-func (r *Room) Del(ch *Channel) bool {
+// Del delete the member from the room.
+func (r *Room) Del(m *member) bool {
 	r.rLock.Lock()
-	if ch.Next != nil {
+	if m.next != nil {
 		// if not footer
-		ch.Next.Prev = ch.Prev
+		m.next.prev = m.prev
 	}
-	if ch.Prev != nil {
+	if m.prev != nil {
 		// if not header
-		ch.Prev.Next = ch.Next
+		m.prev.next = m.next
 	} else {
-		r.next = ch.Next
+		r.next = m.next
 	}
-	ch.Next = nil
-	ch.Prev = nil
+	m.next = nil
+	m.prev = nil
 	r.Online--
 	r.drop = r.Online == 0
 	r.rLock.Unlock()
@@ -69,17 +77,27 @@ func (r *Room) Del(ch *Channel) bool {
 // Push push msg to the room, if chan full discard it.
 func (r *Room) Push(p *protocol.Proto) {
 	r.rLock.RLock()
-	for ch := r.next; ch != nil; ch = ch.Next {
-		_ = ch.Push(p)
+	for m := r.next; m != nil; m = m.next {
+		_ = m.ch.Push(p)
+	}
+	r.rLock.RUnlock()
+}
+
+// Channels get all channels in the room.
+func (r *Room) Channels() (chs []*Channel) {
+	r.rLock.RLock()
+	for m := r.next; m != nil; m = m.next {
+		chs = append(chs, m.ch)
 	}
 	r.rLock.RUnlock()
+	return
 }
 
 // Close close the room.
 func (r *Room) Close() {
 	r.rLock.RLock()
-	for ch := r.next; ch != nil; ch = ch.Next {
-		ch.Close()
+	for m := r.next; m != nil; m = m.next {
+		m.ch.Close()
 	}
 	r.rLock.RUnlock()
 }
@@ -2,11 +2,20 @@ package comet
 
 import (
 	"context"
+	"io"
 	"math/rand"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Terry-Mao/goim/api/logic"
+	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/comet/conf"
+	"github.com/Terry-Mao/goim/pkg/proxy"
+	"github.com/Terry-Mao/goim/pkg/trace"
+	"github.com/Terry-Mao/goim/pkg/websocket"
 	log "github.com/golang/glog"
 	"github.com/zhenjl/cityhash"
 	"google.golang.org/grpc"
@@ -44,6 +53,7 @@ func newLogicClient(c *conf.RPCClient) logic.LogicClient {
 				PermitWithoutStream: true,
 			}),
 			grpc.WithBalancerName(roundrobin.Name),
+			grpc.WithUnaryInterceptor(logicMetrics),
 		}...)
 	if err != nil {
 		panic(err)
@@ -60,15 +70,32 @@ type Server struct {
 
 	serverID  string
 	rpcClient logic.LogicClient
+	wsDeflate *websocket.DeflateConfig // nil if compression disabled
+	proxy     *proxy.Policy            // nil if PROXY protocol disabled
+	certs     []*Certs                 // tls listener certificates
+	slow      *Slow                    // slow-consumer policy
+	culled    uint64                   // stalled connections culled by write deadline
+	debug     *Debugger                // runtime debug tracing
+
+	// drain
+	draining   int32
+	lisLock    sync.Mutex
+	listeners  []io.Closer
+	drainLock  sync.Mutex
+	drainConns map[string]int64 // batched disconnects, key to mid
+	drained    bool             // the batch is flushed, protected by drainLock
 }
 
 // NewServer returns a new Server.
 func NewServer(c *conf.Config) *Server {
 	s := &Server{
-		c:         c,
-		round:     NewRound(c),
-		rpcClient: newLogicClient(c.RPCClient),
+		c:          c,
+		round:      NewRound(c),
+		rpcClient:  newLogicClient(c.RPCClient),
+		drainConns: make(map[string]int64, c.Drain.Batch),
+		slow:       NewSlow(c.Protocol.SlowPolicy, c.Protocol.OverflowSize),
 	}
+	s.debug = NewDebugger(c.Debugger, s.debugChannels)
 	// init bucket
 	s.buckets = make([]*Bucket, c.Bucket.Size)
 	s.bucketIdx = uint32(c.Bucket.Size)
@@ -76,6 +103,23 @@ func NewServer(c *conf.Config) *Server {
 		s.buckets[i] = NewBucket(c.Bucket)
 	}
 	s.serverID = c.Env.Host
+	if c.Websocket.Compress {
+		s.wsDeflate = &websocket.DeflateConfig{
+			Level:                   c.Websocket.CompressLevel,
+			ServerNoContextTakeover: c.Websocket.ServerNoContextTakeover,
+			ClientNoContextTakeover: c.Websocket.ClientNoContextTakeover,
+			MaxInflateSize:          c.Websocket.MaxInflateSize,
+		}
+		if s.wsDeflate.MaxInflateSize <= 0 {
+			s.wsDeflate.MaxInflateSize = int(protocol.MaxPackSize)
+		}
+	}
+	if c.ProxyProtocol.Open {
+		var err error
+		if s.proxy, err = proxy.NewPolicy(c.ProxyProtocol.Trusted, time.Duration(c.ProxyProtocol.Timeout)); err != nil {
+			panic(err)
+		}
+	}
 	go s.onlineproc()
 	return s
 }
@@ -94,13 +138,92 @@ func (s *Server) Bucket(subKey string) *Bucket {
 	return s.buckets[idx]
 }
 
+// proxyConn wraps the conn to read the PROXY protocol header if enabled.
+func (s *Server) proxyConn(conn net.Conn) net.Conn {
+	if s.proxy == nil {
+		return conn
+	}
+	return s.proxy.Wrap(conn)
+}
+
 // RandServerHearbeat rand server heartbeat.
 func (s *Server) RandServerHearbeat() time.Duration {
 	return (minServerHeartbeat + time.Duration(rand.Int63n(int64(maxServerHeartbeat-minServerHeartbeat))))
 }
 
-// Close close the server.
+// Drops get the dropped message counts of the slow consumers per op.
+func (s *Server) Drops() map[int32]uint64 {
+	return s.slow.Drops()
+}
+
+// Culled get the count of stalled connections culled by write deadline.
+func (s *Server) Culled() uint64 {
+	return atomic.LoadUint64(&s.culled)
+}
+
+// setWriteDeadline set the write deadline of conn, zero timeout disabled.
+func (s *Server) setWriteDeadline(conn net.Conn, timeout time.Duration) {
+	if timeout > 0 {
+		_ = conn.SetWriteDeadline(time.Now().Add(timeout))
+	}
+}
+
+// cullStalled count the connection if the dispatch error is a write timeout,
+// the dispatch goroutine closes it then the reader disconnects it as usual.
+func (s *Server) cullStalled(ch *Channel, err error) {
+	if e, ok := err.(net.Error); ok && e.Timeout() {
+		atomic.AddUint64(&s.culled, 1)
+		log.Warningf("key: %s mid: %d stalled connection culled", ch.Key, ch.Mid)
+	}
+}
+
+// stamp stamp the channel seq on a server push if PushSeq, the seqs of the
+// dropped pushes are skipped, and return the gap notification if PushGap.
+func (s *Server) stamp(ch *Channel, p *protocol.Proto) (gap, sp *protocol.Proto) {
+	sp = p
+	if !s.c.Protocol.PushSeq && !s.c.Protocol.PushGap {
+		return
+	}
+	dropped := ch.Dropped()
+	if dropped > 0 && s.c.Protocol.PushGap {
+		gap = &protocol.Proto{Ver: 1, Op: protocol.OpPushGap, Body: []byte(strconv.Itoa(int(dropped)))}
+	}
+	if s.c.Protocol.PushSeq {
+		ch.seq += dropped
+		if gap != nil {
+			gap.Seq = ch.seq
+		}
+		sp = p.Stamp(ch.NextSeq)
+	}
+	return
+}
+
+// traceDispatch start the span of writing the server push if traced.
+func traceDispatch(ch *Channel, p *protocol.Proto) (span *trace.Span) {
+	if p.Trace == "" {
+		return
+	}
+	if span = trace.Start("comet.dispatch", trace.KindInternal, p.Trace); span != nil {
+		span.SetAttr("key", ch.Key)
+		span.SetAttr("mid", ch.Mid)
+		span.SetAttr("op", p.Op)
+	}
+	return
+}
+
+// ReloadCerts reload all tls listener certificates from disk.
+func (s *Server) ReloadCerts() (err error) {
+	for _, c := range s.certs {
+		if err = c.Reload(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Close drain all connections and close the server.
 func (s *Server) Close() (err error) {
+	s.drain()
 	return
 }
 
@@ -123,6 +246,12 @@ func (s *Server) onlineproc() {
 		for _, bucket := range s.buckets {
 			bucket.UpRoomsCount(allRoomsCount)
 		}
+		if culled := s.Culled(); culled > 0 {
+			log.Warningf("stalled connections culled: %d", culled)
+		}
+		if drops := s.slow.Drops(); len(drops) > 0 {
+			log.Warningf("slow consumer %s drops(op:count): %v", s.slow.policy, drops)
+		}
 		time.Sleep(time.Second * 10)
 	}
 }
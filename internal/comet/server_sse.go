@@ -0,0 +1,440 @@
+package comet
+
+import (
+	stdbytes "bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Terry-Mao/goim/api/protocol"
+	"github.com/Terry-Mao/goim/internal/comet/conf"
+	"github.com/Terry-Mao/goim/pkg/bufio"
+	xtime "github.com/Terry-Mao/goim/pkg/time"
+	log "github.com/golang/glog"
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	sseEventPrefix = []byte("data: ")
+	sseEventSuffix = []byte("\n\n")
+)
+
+// sseServer serves server-sent events for downstream and plain http posts
+// for upstream, used by clients which can't upgrade to websocket.
+//
+// GET /sub?token={auth token} opens the event stream, the decoded data of
+// all events is a byte stream of protos in the tcp protocol format, the
+// first proto is OpAuthReply with the session id as body.
+// POST /sub/op?sid={session id} sends protos in the tcp protocol format, at
+// most TCP.ReadBufSize bytes per post.
+type sseServer struct {
+	srv      *Server
+	round    uint64
+	lock     sync.RWMutex
+	sessions map[string]*sseSession
+}
+
+// sseSession is an authed sse connection.
+type sseSession struct {
+	sync.Mutex // serialize the posts, CliProto is single producer
+	ctx        context.Context
+	ch         *Channel
+	b          *Bucket
+	tr         *xtime.Timer
+	trd        *xtime.TimerData
+	hb         time.Duration
+	serverHb   time.Duration
+	lastHb     time.Time
+	closed     bool // the stream is gone, no more posts
+}
+
+// sseConnKey is the context key of the underlying conn of a sse request.
+type sseConnKey struct{}
+
+// sseWriter encodes every write as a server-sent event.
+type sseWriter struct {
+	w io.Writer
+}
+
+func (w *sseWriter) Write(p []byte) (n int, err error) {
+	buf := make([]byte, len(sseEventPrefix)+base64.StdEncoding.EncodedLen(len(p))+len(sseEventSuffix))
+	n = copy(buf, sseEventPrefix)
+	base64.StdEncoding.Encode(buf[n:], p)
+	copy(buf[len(buf)-len(sseEventSuffix):], sseEventSuffix)
+	if _, err = w.w.Write(buf); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// InitSSE listen all sse.bind and start serve server-sent events.
+func InitSSE(server *Server, addrs []string) (err error) {
+	var (
+		bind     string
+		listener net.Listener
+		h        = &sseServer{srv: server, sessions: make(map[string]*sseSession)}
+		mux      = http.NewServeMux()
+	)
+	mux.HandleFunc("/sub", h.sub)
+	mux.HandleFunc("/sub/op", h.operate)
+	for _, bind = range addrs {
+		if listener, err = net.Listen("tcp", bind); err != nil {
+			log.Errorf("net.Listen(tcp, %s) error(%v)", bind, err)
+			return
+		}
+		server.addListener(listener)
+		log.Infof("start sse listen: %s", bind)
+		srv := &http.Server{
+			Handler:           mux,
+			ReadHeaderTimeout: time.Duration(server.c.Protocol.HandshakeTimeout),
+			// the dispatcher sets the write deadlines on the conn
+			ConnContext: func(ctx context.Context, conn net.Conn) context.Context {
+				return context.WithValue(ctx, sseConnKey{}, conn)
+			},
+		}
+		go func(lis net.Listener) {
+			if err := srv.Serve(&meterListener{Listener: lis, transport: "sse"}); err != nil {
+				log.Errorf("sse serve(%s) error(%v)", lis.Addr().String(), err)
+			}
+		}(listener)
+	}
+	return
+}
+
+func (h *sseServer) session(sid string) (sess *sseSession) {
+	h.lock.RLock()
+	sess = h.sessions[sid]
+	h.lock.RUnlock()
+	return
+}
+
+func (h *sseServer) addSession(sid string, sess *sseSession) {
+	h.lock.Lock()
+	h.sessions[sid] = sess
+	h.lock.Unlock()
+}
+
+func (h *sseServer) delSession(sid string) {
+	h.lock.Lock()
+	delete(h.sessions, sid)
+	h.lock.Unlock()
+}
+
+// sub serve a sse connection.
+func (h *sseServer) sub(w http.ResponseWriter, r *http.Request) {
+	var (
+		err     error
+		rid     string
+		accepts []int32
+		hb      time.Duration
+		expire  int64
+		p       *protocol.Proto
+		b       *Bucket
+		trd     *xtime.TimerData
+		s       = h.srv
+		rn      = int(atomic.AddUint64(&h.round, 1) % maxInt)
+		tr      = s.round.Timer(rn)
+		wp      = s.round.Writer(rn)
+		ch      = NewChannel(s.c.Protocol.CliProto, s.c.Protocol.SvrProto, s.slow)
+		sid     = uuid.New().String()
+		done    = make(chan struct{})
+		conn, _ = r.Context().Value(sseConnKey{}).(net.Conn)
+	)
+	if r.Method != http.MethodGet {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	ch.conn = closerFunc(cancel)
+	// handshake, the timer turns to the heartbeat timeout once handshook
+	var handshook int32
+	trd = tr.Add(time.Duration(s.c.Protocol.HandshakeTimeout), func() {
+		cancel()
+		if atomic.LoadInt32(&handshook) == 0 {
+			handshakeFailures.WithLabelValues("sse", "timeout").Inc()
+		} else {
+			heartbeatTimeouts.WithLabelValues("sse").Inc()
+		}
+		log.Errorf("key: %s remoteIP: %s sse timeout", ch.Key, r.RemoteAddr)
+	})
+	ch.IP, _, _ = net.SplitHostPort(r.RemoteAddr)
+	// must not setadv, only used in auth
+	if p, err = ch.CliProto.Set(); err == nil {
+		p.Op = protocol.OpAuth
+		p.Body = []byte(r.URL.Query().Get("token"))
+		if ch.Mid, ch.Key, rid, accepts, hb, expire, err = s.Connect(ctx, p, r.Header.Get("Cookie"), ch); err == nil {
+			ch.Watch(accepts...)
+			b = s.Bucket(ch.Key)
+			if err = b.Put(rid, ch); err != nil {
+				handshakeFailures.WithLabelValues("sse", "bucket").Inc()
+			}
+			if conf.Conf.Debug {
+				log.Infof("sse connected key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
+			}
+		} else {
+			handshakeFailures.WithLabelValues("sse", "auth").Inc()
+		}
+	}
+	if err != nil {
+		tr.Del(trd)
+		switch status.Code(err) {
+		case codes.Unauthenticated:
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		case codes.PermissionDenied:
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		default:
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+		}
+		log.Errorf("key: %s remoteIP: %s sse handshake failed error(%v)", ch.Key, r.RemoteAddr, err)
+		return
+	}
+	handshakes.WithLabelValues("sse").Inc()
+	connections.WithLabelValues("sse").Inc()
+	trd.Key = ch.Key
+	atomic.StoreInt32(&handshook, 1)
+	tr.Set(trd, hb)
+	s.watchExpiry(tr, ch, expire)
+	s.debug.Attach(ch)
+	s.debug.Printf(ch, DebugAuth, "room: %s accepts: %v", rid, accepts)
+	wb := wp.Get()
+	ch.Writer.ResetBuffer(&sseWriter{w: w}, wb.Bytes())
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("X-Accel-Buffering", "no")
+	p.Op = protocol.OpAuthReply
+	p.Body = []byte(sid)
+	s.setWriteDeadline(conn, time.Duration(s.c.Protocol.WriteTimeout))
+	if err = p.WriteTCP(&ch.Writer); err == nil {
+		if err = ch.Writer.Flush(); err == nil {
+			flusher.Flush()
+		}
+	}
+	if err != nil {
+		cancel()
+	}
+	sess := &sseSession{
+		ctx:      ctx,
+		ch:       ch,
+		b:        b,
+		tr:       tr,
+		trd:      trd,
+		hb:       hb,
+		serverHb: s.RandServerHearbeat(),
+		lastHb:   time.Now(),
+	}
+	h.addSession(sid, sess)
+	go func() {
+		s.dispatchSSE(conn, flusher, ch, cancel)
+		close(done)
+		cancel()
+	}()
+	// wait the client gone, heartbeat timeout or dispatch exit
+	<-ctx.Done()
+	s.debug.Printf(ch, DebugDisconnect, "stream error(%v)", ctx.Err())
+	h.delSession(sid)
+	connections.WithLabelValues("sse").Dec()
+	// wait the in-flight post, which may be changing the rooms of the channel
+	sess.Lock()
+	sess.closed = true
+	b.Del(ch)
+	tr.Del(trd)
+	ch.expiry.stop()
+	sess.Unlock()
+	select {
+	case <-done:
+	default:
+		ch.Close()
+		<-done
+	}
+	s.debug.Detach(ch)
+	wp.Put(wb)
+	if err = s.Disconnect(context.Background(), ch.Mid, ch.Key); err != nil {
+		log.Errorf("key: %s mid: %d operator do disconnect error(%v)", ch.Key, ch.Mid, err)
+	}
+	if conf.Conf.Debug {
+		log.Infof("sse disconnected key: %s mid: %d", ch.Key, ch.Mid)
+	}
+}
+
+// operate serve the upstream protos of a sse connection.
+func (h *sseServer) operate(w http.ResponseWriter, r *http.Request) {
+	var (
+		err  error
+		body []byte
+		p    *protocol.Proto
+		s    = h.srv
+	)
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	sess := h.session(r.URL.Query().Get("sid"))
+	if sess == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	if body, err = ioutil.ReadAll(io.LimitReader(r.Body, int64(s.c.TCP.ReadBufSize)+1)); err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	if len(body) > s.c.TCP.ReadBufSize {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+	ch := sess.ch
+	rr := bufio.NewReaderSize(stdbytes.NewReader(body), len(body))
+	sess.Lock()
+	if sess.closed {
+		sess.Unlock()
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	for {
+		if p, err = ch.CliProto.Set(); err != nil {
+			break
+		}
+		if err = p.ReadTCP(rr); err != nil {
+			break
+		}
+		s.debug.Printf(ch, DebugRead, "proto: %v", p)
+		if p.Op == protocol.OpHeartbeat {
+			sess.tr.Set(sess.trd, sess.hb)
+			p.Op = protocol.OpHeartbeatReply
+			p.Body = nil
+			// NOTE: send server heartbeat for a long time
+			if now := time.Now(); now.Sub(sess.lastHb) > sess.serverHb {
+				if err1 := s.Heartbeat(r.Context(), ch.Mid, ch.Key); err1 == nil {
+					sess.lastHb = now
+				}
+			}
+			if conf.Conf.Debug {
+				log.Infof("sse heartbeat receive key:%s, mid:%d", ch.Key, ch.Mid)
+			}
+		} else {
+			if err = s.Operate(r.Context(), p, ch, sess.b); err != nil {
+				break
+			}
+		}
+		ch.CliProto.SetAdv()
+		ch.Signal()
+	}
+	sess.Unlock()
+	if err != io.EOF {
+		log.Errorf("key: %s sse operate error(%v)", ch.Key, err)
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// dispatchSSE write the pushed and replied protos to the event stream.
+func (s *Server) dispatchSSE(conn net.Conn, flusher http.Flusher, ch *Channel, cancel context.CancelFunc) {
+	var (
+		err    error
+		finish bool
+		gap    *protocol.Proto
+		online int32
+		rooms  []byte
+		wr     = &ch.Writer
+	)
+	if conf.Conf.Debug {
+		log.Infof("key: %s start dispatch sse goroutine", ch.Key)
+	}
+	for {
+		var p = ch.Ready()
+		if p != protocol.ProtoFinish {
+			s.setWriteDeadline(conn, time.Duration(s.c.Protocol.WriteTimeout))
+		}
+		switch p {
+		case protocol.ProtoFinish:
+			if conf.Conf.Debug {
+				log.Infof("key: %s wakeup exit dispatch goroutine", ch.Key)
+			}
+			finish = true
+			goto failed
+		case protocol.ProtoReady:
+			// fetch message from svrbox(client send)
+			for {
+				if p, err = ch.CliProto.Get(); err != nil {
+					break
+				}
+				if p.Op == protocol.OpHeartbeatReply {
+					online, rooms = ch.Online()
+					if err = p.WriteTCPHeart(wr, online, rooms); err != nil {
+						goto failed
+					}
+				} else {
+					if err = p.WriteTCP(wr); err != nil {
+						goto failed
+					}
+				}
+				s.debug.Printf(ch, DebugReply, "proto: %v", p)
+				p.Body = nil // avoid memory leak
+				ch.CliProto.GetAdv()
+			}
+		default:
+			// server send
+			if gap, p = s.stamp(ch, p); gap != nil {
+				if err = gap.WriteTCP(wr); err != nil {
+					goto failed
+				}
+			}
+			span := traceDispatch(ch, p)
+			err = p.WriteTCP(wr)
+			span.Finish(err)
+			if err != nil {
+				goto failed
+			}
+			s.debug.Printf(ch, DebugPush, "proto: %v", p)
+			if conf.Conf.Debug {
+				log.Infof("sse sent a message key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
+			}
+			if p.Op == protocol.OpDisconnectReply {
+				// server draining, close after the reply flushed
+				if err = wr.Flush(); err == nil {
+					flusher.Flush()
+				}
+				cancel()
+				goto failed
+			}
+		}
+		// only hungry flush response
+		s.setWriteDeadline(conn, time.Duration(s.c.Protocol.FlushTimeout))
+		if err = wr.Flush(); err != nil {
+			break
+		}
+		flusher.Flush()
+		s.debug.Printf(ch, DebugFlush, "flushed")
+	}
+failed:
+	s.debug.Printf(ch, DebugDisconnect, "dispatch error(%v)", err)
+	if err != nil {
+		s.cullStalled(ch, err)
+		log.Errorf("key: %s dispatch sse error(%v)", ch.Key, err)
+		cancel()
+	}
+	// the conn may be kept alive for the next request
+	_ = conn.SetWriteDeadline(time.Time{})
+	// must ensure all channel message discard, for reader won't blocking Signal
+	for !finish {
+		finish = (ch.Ready() == protocol.ProtoFinish)
+	}
+	if conf.Conf.Debug {
+		log.Infof("key: %s dispatch goroutine exit", ch.Key)
+	}
+}
@@ -2,9 +2,11 @@ package comet
 
 import (
 	"context"
+	"crypto/tls"
 	"io"
 	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Terry-Mao/goim/api/protocol"
@@ -35,10 +37,43 @@ func InitTCP(server *Server, addrs []string, accept int) (err error) {
 			log.Errorf("net.ListenTCP(tcp, %s) error(%v)", bind, err)
 			return
 		}
+		server.addListener(listener)
 		log.Infof("start tcp listen: %s", bind)
 		// split N core accept
 		for i := 0; i < accept; i++ {
-			go acceptTCP(server, listener)
+			go acceptTCP(server, listener, nil)
+		}
+	}
+	return
+}
+
+// InitTCPWithTLS listen all tcp.tlsbind and start accept tls connections.
+func InitTCPWithTLS(server *Server, addrs []string, certFile, privateFile string, accept int) (err error) {
+	var (
+		bind     string
+		listener *net.TCPListener
+		addr     *net.TCPAddr
+		certs    *Certs
+	)
+	if certs, err = NewCerts(certFile, privateFile); err != nil {
+		return
+	}
+	server.certs = append(server.certs, certs)
+	tlsCfg := certs.TLSConfig()
+	for _, bind = range addrs {
+		if addr, err = net.ResolveTCPAddr("tcp", bind); err != nil {
+			log.Errorf("net.ResolveTCPAddr(tcp, %s) error(%v)", bind, err)
+			return
+		}
+		if listener, err = net.ListenTCP("tcp", addr); err != nil {
+			log.Errorf("net.ListenTCP(tcp, %s) error(%v)", bind, err)
+			return
+		}
+		server.addListener(listener)
+		log.Infof("start tcp tls listen: %s", bind)
+		// split N core accept
+		for i := 0; i < accept; i++ {
+			go acceptTCP(server, listener, tlsCfg)
 		}
 	}
 	return
@@ -46,8 +81,8 @@ func InitTCP(server *Server, addrs []string, accept int) (err error) {
 
 // Accept accepts connections on the listener and serves requests
 // for each incoming connection.  Accept blocks; the caller typically
-// invokes it in a go statement.
-func acceptTCP(server *Server, lis *net.TCPListener) {
+// invokes it in a go statement. tlsCfg is nil for plain tcp.
+func acceptTCP(server *Server, lis *net.TCPListener, tlsCfg *tls.Config) {
 	var (
 		conn *net.TCPConn
 		err  error
@@ -71,14 +106,19 @@ func acceptTCP(server *Server, lis *net.TCPListener) {
 			log.Errorf("conn.SetWriteBuffer() error(%v)", err)
 			return
 		}
-		go serveTCP(server, conn, r)
+		if tlsCfg != nil {
+			// PROXY protocol header is sent before tls handshake
+			go serveTCP(server, tls.Server(server.proxyConn(conn), tlsCfg), r)
+		} else {
+			go serveTCP(server, server.proxyConn(conn), r)
+		}
 		if r++; r == maxInt {
 			r = 0
 		}
 	}
 }
 
-func serveTCP(s *Server, conn *net.TCPConn, r int) {
+func serveTCP(s *Server, conn net.Conn, r int) {
 	var (
 		// timer
 		tr = s.round.Timer(r)
@@ -91,48 +131,61 @@ func serveTCP(s *Server, conn *net.TCPConn, r int) {
 	if conf.Conf.Debug {
 		log.Infof("start tcp serve \"%s\" with \"%s\"", lAddr, rAddr)
 	}
-	s.ServeTCP(conn, rp, wp, tr)
+	s.ServeTCP(newMeterConn(conn, "tcp"), rp, wp, tr)
 }
 
 // ServeTCP serve a tcp connection.
-func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer) {
+func (s *Server) ServeTCP(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Timer) {
 	var (
 		err     error
 		rid     string
 		accepts []int32
 		hb      time.Duration
-		white   bool
+		expire  int64
 		p       *protocol.Proto
 		b       *Bucket
 		trd     *xtime.TimerData
 		lastHb  = time.Now()
 		rb      = rp.Get()
 		wb      = wp.Get()
-		ch      = NewChannel(s.c.Protocol.CliProto, s.c.Protocol.SvrProto)
+		ch      = NewChannel(s.c.Protocol.CliProto, s.c.Protocol.SvrProto, s.slow)
 		rr      = &ch.Reader
 		wr      = &ch.Writer
 	)
 	ch.Reader.ResetBuffer(conn, rb.Bytes())
+	ch.conn = conn
 	ch.Writer.ResetBuffer(conn, wb.Bytes())
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	// handshake
+	// handshake, the timer turns to the heartbeat timeout once handshook
+	var handshook int32
 	step := 0
 	trd = tr.Add(time.Duration(s.c.Protocol.HandshakeTimeout), func() {
+		// NOTE: fix close block for tls
+		_ = conn.SetDeadline(time.Now().Add(time.Millisecond * 100))
 		conn.Close()
+		if atomic.LoadInt32(&handshook) == 0 {
+			handshakeFailures.WithLabelValues("tcp", "timeout").Inc()
+		} else {
+			heartbeatTimeouts.WithLabelValues("tcp").Inc()
+		}
 		log.Errorf("key: %s remoteIP: %s step: %d tcp handshake timeout", ch.Key, conn.RemoteAddr().String(), step)
 	})
 	ch.IP, _, _ = net.SplitHostPort(conn.RemoteAddr().String())
 	// must not setadv, only used in auth
 	step = 1
 	if p, err = ch.CliProto.Set(); err == nil {
-		if ch.Mid, ch.Key, rid, accepts, hb, err = s.authTCP(ctx, rr, wr, p); err == nil {
+		if ch.Mid, ch.Key, rid, accepts, hb, expire, err = s.authTCP(ctx, rr, wr, p, ch); err == nil {
 			ch.Watch(accepts...)
 			b = s.Bucket(ch.Key)
-			err = b.Put(rid, ch)
+			if err = b.Put(rid, ch); err != nil {
+				handshakeFailures.WithLabelValues("tcp", "bucket").Inc()
+			}
 			if conf.Conf.Debug {
 				log.Infof("tcp connnected key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
 			}
+		} else {
+			handshakeFailures.WithLabelValues("tcp", "auth").Inc()
 		}
 	}
 	step = 2
@@ -144,12 +197,14 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 		log.Errorf("key: %s handshake failed error(%v)", ch.Key, err)
 		return
 	}
+	handshakes.WithLabelValues("tcp").Inc()
+	connections.WithLabelValues("tcp").Inc()
 	trd.Key = ch.Key
+	atomic.StoreInt32(&handshook, 1)
 	tr.Set(trd, hb)
-	white = whitelist.Contains(ch.Mid)
-	if white {
-		whitelist.Printf("key: %s[%s] auth\n", ch.Key, rid)
-	}
+	s.watchExpiry(tr, ch, expire)
+	s.debug.Attach(ch)
+	s.debug.Printf(ch, DebugAuth, "room: %s accepts: %v", rid, accepts)
 	step = 3
 	// hanshake ok start dispatch goroutine
 	go s.dispatchTCP(conn, wr, wp, wb, ch)
@@ -158,15 +213,10 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 		if p, err = ch.CliProto.Set(); err != nil {
 			break
 		}
-		if white {
-			whitelist.Printf("key: %s start read proto\n", ch.Key)
-		}
 		if err = p.ReadTCP(rr); err != nil {
 			break
 		}
-		if white {
-			whitelist.Printf("key: %s read proto:%v\n", ch.Key, p)
-		}
+		s.debug.Printf(ch, DebugRead, "proto: %v", p)
 		if p.Op == protocol.OpHeartbeat {
 			tr.Set(trd, hb)
 			p.Op = protocol.OpHeartbeatReply
@@ -186,32 +236,24 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 				break
 			}
 		}
-		if white {
-			whitelist.Printf("key: %s process proto:%v\n", ch.Key, p)
-		}
 		ch.CliProto.SetAdv()
 		ch.Signal()
-		if white {
-			whitelist.Printf("key: %s signal\n", ch.Key)
-		}
-	}
-	if white {
-		whitelist.Printf("key: %s server tcp error(%v)\n", ch.Key, err)
 	}
+	s.debug.Printf(ch, DebugDisconnect, "read error(%v)", err)
 	if err != nil && err != io.EOF && !strings.Contains(err.Error(), "closed") {
 		log.Errorf("key: %s server tcp failed error(%v)", ch.Key, err)
 	}
+	connections.WithLabelValues("tcp").Dec()
 	b.Del(ch)
 	tr.Del(trd)
+	ch.expiry.stop()
+	s.debug.Detach(ch)
 	rp.Put(rb)
 	conn.Close()
 	ch.Close()
 	if err = s.Disconnect(ctx, ch.Mid, ch.Key); err != nil {
 		log.Errorf("key: %s mid: %d operator do disconnect error(%v)", ch.Key, ch.Mid, err)
 	}
-	if white {
-		whitelist.Printf("key: %s mid: %d disconnect error(%v)\n", ch.Key, ch.Mid, err)
-	}
 	if conf.Conf.Debug {
 		log.Infof("tcp disconnected key: %s mid: %d", ch.Key, ch.Mid)
 	}
@@ -220,32 +262,27 @@ func (s *Server) ServeTCP(conn *net.TCPConn, rp, wp *bytes.Pool, tr *xtime.Timer
 // dispatch accepts connections on the listener and serves requests
 // for each incoming connection.  dispatch blocks; the caller typically
 // invokes it in a go statement.
-func (s *Server) dispatchTCP(conn *net.TCPConn, wr *bufio.Writer, wp *bytes.Pool, wb *bytes.Buffer, ch *Channel) {
+func (s *Server) dispatchTCP(conn net.Conn, wr *bufio.Writer, wp *bytes.Pool, wb *bytes.Buffer, ch *Channel) {
 	var (
 		err    error
 		finish bool
+		gap    *protocol.Proto
 		online int32
-		white  = whitelist.Contains(ch.Mid)
+		rooms  []byte
 	)
 	if conf.Conf.Debug {
 		log.Infof("key: %s start dispatch tcp goroutine", ch.Key)
 	}
 	for {
-		if white {
-			whitelist.Printf("key: %s wait proto ready\n", ch.Key)
-		}
 		var p = ch.Ready()
-		if white {
-			whitelist.Printf("key: %s proto ready\n", ch.Key)
-		}
 		if conf.Conf.Debug {
 			log.Infof("key:%s dispatch msg:%v", ch.Key, *p)
 		}
+		if p != protocol.ProtoFinish {
+			s.setWriteDeadline(conn, time.Duration(s.c.Protocol.WriteTimeout))
+		}
 		switch p {
 		case protocol.ProtoFinish:
-			if white {
-				whitelist.Printf("key: %s receive proto finish\n", ch.Key)
-			}
 			if conf.Conf.Debug {
 				log.Infof("key: %s wakeup exit dispatch goroutine", ch.Key)
 			}
@@ -257,14 +294,9 @@ func (s *Server) dispatchTCP(conn *net.TCPConn, wr *bufio.Writer, wp *bytes.Pool
 				if p, err = ch.CliProto.Get(); err != nil {
 					break
 				}
-				if white {
-					whitelist.Printf("key: %s start write client proto%v\n", ch.Key, p)
-				}
 				if p.Op == protocol.OpHeartbeatReply {
-					if ch.Room != nil {
-						online = ch.Room.OnlineNum()
-					}
-					if err = p.WriteTCPHeart(wr, online); err != nil {
+					online, rooms = ch.Online()
+					if err = p.WriteTCPHeart(wr, online, rooms); err != nil {
 						goto failed
 					}
 				} else {
@@ -272,43 +304,44 @@ func (s *Server) dispatchTCP(conn *net.TCPConn, wr *bufio.Writer, wp *bytes.Pool
 						goto failed
 					}
 				}
-				if white {
-					whitelist.Printf("key: %s write client proto%v\n", ch.Key, p)
-				}
+				s.debug.Printf(ch, DebugReply, "proto: %v", p)
 				p.Body = nil // avoid memory leak
 				ch.CliProto.GetAdv()
 			}
 		default:
-			if white {
-				whitelist.Printf("key: %s start write server proto%v\n", ch.Key, p)
-			}
 			// server send
-			if err = p.WriteTCP(wr); err != nil {
-				goto failed
+			if gap, p = s.stamp(ch, p); gap != nil {
+				if err = gap.WriteTCP(wr); err != nil {
+					goto failed
+				}
 			}
-			if white {
-				whitelist.Printf("key: %s write server proto%v\n", ch.Key, p)
+			span := traceDispatch(ch, p)
+			err = p.WriteTCP(wr)
+			span.Finish(err)
+			if err != nil {
+				goto failed
 			}
+			s.debug.Printf(ch, DebugPush, "proto: %v", p)
 			if conf.Conf.Debug {
 				log.Infof("tcp sent a message key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
 			}
-		}
-		if white {
-			whitelist.Printf("key: %s start flush \n", ch.Key)
+			if p.Op == protocol.OpDisconnectReply {
+				// server draining, close after the reply flushed
+				err = wr.Flush()
+				goto failed
+			}
 		}
 		// only hungry flush response
+		s.setWriteDeadline(conn, time.Duration(s.c.Protocol.FlushTimeout))
 		if err = wr.Flush(); err != nil {
 			break
 		}
-		if white {
-			whitelist.Printf("key: %s flush\n", ch.Key)
-		}
+		s.debug.Printf(ch, DebugFlush, "flushed")
 	}
 failed:
-	if white {
-		whitelist.Printf("key: %s dispatch tcp error(%v)\n", ch.Key, err)
-	}
+	s.debug.Printf(ch, DebugDisconnect, "dispatch error(%v)", err)
 	if err != nil {
+		s.cullStalled(ch, err)
 		log.Errorf("key: %s dispatch tcp error(%v)", ch.Key, err)
 	}
 	conn.Close()
@@ -323,7 +356,7 @@ failed:
 }
 
 // auth for goim handshake with client, use rsa & aes.
-func (s *Server) authTCP(ctx context.Context, rr *bufio.Reader, wr *bufio.Writer, p *protocol.Proto) (mid int64, key, rid string, accepts []int32, hb time.Duration, err error) {
+func (s *Server) authTCP(ctx context.Context, rr *bufio.Reader, wr *bufio.Writer, p *protocol.Proto, ch *Channel) (mid int64, key, rid string, accepts []int32, hb time.Duration, expire int64, err error) {
 	for {
 		if err = p.ReadTCP(rr); err != nil {
 			return
@@ -334,8 +367,13 @@ func (s *Server) authTCP(ctx context.Context, rr *bufio.Reader, wr *bufio.Writer
 			log.Errorf("tcp request operation(%d) not auth", p.Op)
 		}
 	}
-	if mid, key, rid, accepts, hb, err = s.Connect(ctx, p, ""); err != nil {
+	if mid, key, rid, accepts, hb, expire, err = s.Connect(ctx, p, "", ch); err != nil {
 		log.Errorf("authTCP.Connect(key:%v).err(%v)", key, err)
+		// reply the failure before close
+		authFailed(p, err)
+		if p.WriteTCP(wr) == nil {
+			_ = wr.Flush()
+		}
 		return
 	}
 	p.Op = protocol.OpAuthReply
@@ -6,6 +6,7 @@ import (
 	"io"
 	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/Terry-Mao/goim/api/protocol"
@@ -32,6 +33,7 @@ func InitWebsocket(server *Server, addrs []string, accept int) (err error) {
 			log.Errorf("net.ListenTCP(tcp, %s) error(%v)", bind, err)
 			return
 		}
+		server.addListener(listener)
 		log.Infof("start ws listen: %s", bind)
 		// split N core accept
 		for i := 0; i < accept; i++ {
@@ -46,30 +48,23 @@ func InitWebsocketWithTLS(server *Server, addrs []string, certFile, privateFile
 	var (
 		bind     string
 		listener net.Listener
-		cert     tls.Certificate
-		certs    []tls.Certificate
+		certs    *Certs
 	)
-	certFiles := strings.Split(certFile, ",")
-	privateFiles := strings.Split(privateFile, ",")
-	for i := range certFiles {
-		cert, err = tls.LoadX509KeyPair(certFiles[i], privateFiles[i])
-		if err != nil {
-			log.Errorf("Error loading certificate. error(%v)", err)
-			return
-		}
-		certs = append(certs, cert)
+	if certs, err = NewCerts(certFile, privateFile); err != nil {
+		return
 	}
-	tlsCfg := &tls.Config{Certificates: certs}
-	tlsCfg.BuildNameToCertificate()
+	server.certs = append(server.certs, certs)
+	tlsCfg := certs.TLSConfig()
 	for _, bind = range addrs {
-		if listener, err = tls.Listen("tcp", bind, tlsCfg); err != nil {
+		if listener, err = net.Listen("tcp", bind); err != nil {
 			log.Errorf("net.ListenTCP(tcp, %s) error(%v)", bind, err)
 			return
 		}
+		server.addListener(listener)
 		log.Infof("start wss listen: %s", bind)
 		// split N core accept
 		for i := 0; i < accept; i++ {
-			go acceptWebsocketWithTLS(server, listener)
+			go acceptWebsocketWithTLS(server, listener, tlsCfg)
 		}
 	}
 	return
@@ -102,7 +97,7 @@ func acceptWebsocket(server *Server, lis *net.TCPListener) {
 			log.Errorf("conn.SetWriteBuffer() error(%v)", err)
 			return
 		}
-		go serveWebsocket(server, conn, r)
+		go serveWebsocket(server, server.proxyConn(conn), r)
 		if r++; r == maxInt {
 			r = 0
 		}
@@ -112,7 +107,7 @@ func acceptWebsocket(server *Server, lis *net.TCPListener) {
 // Accept accepts connections on the listener and serves requests
 // for each incoming connection.  Accept blocks; the caller typically
 // invokes it in a go statement.
-func acceptWebsocketWithTLS(server *Server, lis net.Listener) {
+func acceptWebsocketWithTLS(server *Server, lis net.Listener, tlsCfg *tls.Config) {
 	var (
 		conn net.Conn
 		err  error
@@ -124,7 +119,8 @@ func acceptWebsocketWithTLS(server *Server, lis net.Listener) {
 			log.Errorf("listener.Accept(\"%s\") error(%v)", lis.Addr().String(), err)
 			return
 		}
-		go serveWebsocket(server, conn, r)
+		// PROXY protocol header is sent before tls handshake
+		go serveWebsocket(server, tls.Server(server.proxyConn(conn), tlsCfg), r)
 		if r++; r == maxInt {
 			r = 0
 		}
@@ -144,7 +140,7 @@ func serveWebsocket(s *Server, conn net.Conn, r int) {
 		rAddr := conn.RemoteAddr().String()
 		log.Infof("start tcp serve \"%s\" with \"%s\"", lAddr, rAddr)
 	}
-	s.ServeWebsocket(conn, rp, wp, tr)
+	s.ServeWebsocket(newMeterConn(conn, "websocket"), rp, wp, tr)
 }
 
 // ServeWebsocket serve a websocket connection.
@@ -154,13 +150,13 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 		rid     string
 		accepts []int32
 		hb      time.Duration
-		white   bool
+		expire  int64
 		p       *protocol.Proto
 		b       *Bucket
 		trd     *xtime.TimerData
 		lastHB  = time.Now()
 		rb      = rp.Get()
-		ch      = NewChannel(s.c.Protocol.CliProto, s.c.Protocol.SvrProto)
+		ch      = NewChannel(s.c.Protocol.CliProto, s.c.Protocol.SvrProto, s.slow)
 		rr      = &ch.Reader
 		wr      = &ch.Writer
 		ws      *websocket.Conn // websocket
@@ -168,20 +164,28 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 	)
 	// reader
 	ch.Reader.ResetBuffer(conn, rb.Bytes())
+	ch.conn = conn
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
-	// handshake
+	// handshake, the timer turns to the heartbeat timeout once handshook
+	var handshook int32
 	step := 0
 	trd = tr.Add(time.Duration(s.c.Protocol.HandshakeTimeout), func() {
 		// NOTE: fix close block for tls
 		_ = conn.SetDeadline(time.Now().Add(time.Millisecond * 100))
 		_ = conn.Close()
+		if atomic.LoadInt32(&handshook) == 0 {
+			handshakeFailures.WithLabelValues("websocket", "timeout").Inc()
+		} else {
+			heartbeatTimeouts.WithLabelValues("websocket").Inc()
+		}
 		log.Errorf("key: %s remoteIP: %s step: %d ws handshake timeout", ch.Key, conn.RemoteAddr().String(), step)
 	})
 	// websocket
 	ch.IP, _, _ = net.SplitHostPort(conn.RemoteAddr().String())
 	step = 1
 	if req, err = websocket.ReadRequest(rr); err != nil || req.RequestURI != "/sub" {
+		handshakeFailures.WithLabelValues("websocket", "request").Inc()
 		conn.Close()
 		tr.Del(trd)
 		rp.Put(rb)
@@ -194,7 +198,8 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 	wb := wp.Get()
 	ch.Writer.ResetBuffer(conn, wb.Bytes())
 	step = 2
-	if ws, err = websocket.Upgrade(conn, rr, wr, req); err != nil {
+	if ws, err = websocket.UpgradeWithDeflate(conn, rr, wr, req, s.wsDeflate); err != nil {
+		handshakeFailures.WithLabelValues("websocket", "upgrade").Inc()
 		conn.Close()
 		tr.Del(trd)
 		rp.Put(rb)
@@ -207,13 +212,17 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 	// must not setadv, only used in auth
 	step = 3
 	if p, err = ch.CliProto.Set(); err == nil {
-		if ch.Mid, ch.Key, rid, accepts, hb, err = s.authWebsocket(ctx, ws, p, req.Header.Get("Cookie")); err == nil {
+		if ch.Mid, ch.Key, rid, accepts, hb, expire, err = s.authWebsocket(ctx, ws, p, req.Header.Get("Cookie"), ch); err == nil {
 			ch.Watch(accepts...)
 			b = s.Bucket(ch.Key)
-			err = b.Put(rid, ch)
+			if err = b.Put(rid, ch); err != nil {
+				handshakeFailures.WithLabelValues("websocket", "bucket").Inc()
+			}
 			if conf.Conf.Debug {
 				log.Infof("websocket connected key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
 			}
+		} else {
+			handshakeFailures.WithLabelValues("websocket", "auth").Inc()
 		}
 	}
 	step = 4
@@ -227,29 +236,26 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 		}
 		return
 	}
+	handshakes.WithLabelValues("websocket").Inc()
+	connections.WithLabelValues("websocket").Inc()
 	trd.Key = ch.Key
+	atomic.StoreInt32(&handshook, 1)
 	tr.Set(trd, hb)
-	white = whitelist.Contains(ch.Mid)
-	if white {
-		whitelist.Printf("key: %s[%s] auth\n", ch.Key, rid)
-	}
+	s.watchExpiry(tr, ch, expire)
+	s.debug.Attach(ch)
+	s.debug.Printf(ch, DebugAuth, "room: %s accepts: %v", rid, accepts)
 	// handshake ok start dispatch goroutine
 	step = 5
-	go s.dispatchWebsocket(ws, wp, wb, ch)
+	go s.dispatchWebsocket(conn, ws, wp, wb, ch)
 	serverHeartbeat := s.RandServerHearbeat()
 	for {
 		if p, err = ch.CliProto.Set(); err != nil {
 			break
 		}
-		if white {
-			whitelist.Printf("key: %s start read proto\n", ch.Key)
-		}
 		if err = p.ReadWebsocket(ws); err != nil {
 			break
 		}
-		if white {
-			whitelist.Printf("key: %s read proto:%v\n", ch.Key, p)
-		}
+		s.debug.Printf(ch, DebugRead, "proto: %v", p)
 		if p.Op == protocol.OpHeartbeat {
 			tr.Set(trd, hb)
 			p.Op = protocol.OpHeartbeatReply
@@ -269,32 +275,24 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 				break
 			}
 		}
-		if white {
-			whitelist.Printf("key: %s process proto:%v\n", ch.Key, p)
-		}
 		ch.CliProto.SetAdv()
 		ch.Signal()
-		if white {
-			whitelist.Printf("key: %s signal\n", ch.Key)
-		}
-	}
-	if white {
-		whitelist.Printf("key: %s server tcp error(%v)\n", ch.Key, err)
 	}
+	s.debug.Printf(ch, DebugDisconnect, "read error(%v)", err)
 	if err != nil && err != io.EOF && err != websocket.ErrMessageClose && !strings.Contains(err.Error(), "closed") {
 		log.Errorf("key: %s server ws failed error(%v)", ch.Key, err)
 	}
+	connections.WithLabelValues("websocket").Dec()
 	b.Del(ch)
 	tr.Del(trd)
+	ch.expiry.stop()
+	s.debug.Detach(ch)
 	ws.Close()
 	ch.Close()
 	rp.Put(rb)
 	if err = s.Disconnect(ctx, ch.Mid, ch.Key); err != nil {
 		log.Errorf("key: %s operator do disconnect error(%v)", ch.Key, err)
 	}
-	if white {
-		whitelist.Printf("key: %s disconnect error(%v)\n", ch.Key, err)
-	}
 	if conf.Conf.Debug {
 		log.Infof("websocket disconnected key: %s mid:%d", ch.Key, ch.Mid)
 	}
@@ -303,32 +301,27 @@ func (s *Server) ServeWebsocket(conn net.Conn, rp, wp *bytes.Pool, tr *xtime.Tim
 // dispatch accepts connections on the listener and serves requests
 // for each incoming connection.  dispatch blocks; the caller typically
 // invokes it in a go statement.
-func (s *Server) dispatchWebsocket(ws *websocket.Conn, wp *bytes.Pool, wb *bytes.Buffer, ch *Channel) {
+func (s *Server) dispatchWebsocket(conn net.Conn, ws *websocket.Conn, wp *bytes.Pool, wb *bytes.Buffer, ch *Channel) {
 	var (
 		err    error
 		finish bool
+		gap    *protocol.Proto
 		online int32
-		white  = whitelist.Contains(ch.Mid)
+		rooms  []byte
 	)
 	if conf.Conf.Debug {
 		log.Infof("key: %s start dispatch tcp goroutine", ch.Key)
 	}
 	for {
-		if white {
-			whitelist.Printf("key: %s wait proto ready\n", ch.Key)
-		}
 		var p = ch.Ready()
-		if white {
-			whitelist.Printf("key: %s proto ready\n", ch.Key)
-		}
 		if conf.Conf.Debug {
 			log.Infof("key:%s dispatch msg:%s", ch.Key, p.Body)
 		}
+		if p != protocol.ProtoFinish {
+			s.setWriteDeadline(conn, time.Duration(s.c.Protocol.WriteTimeout))
+		}
 		switch p {
 		case protocol.ProtoFinish:
-			if white {
-				whitelist.Printf("key: %s receive proto finish\n", ch.Key)
-			}
 			if conf.Conf.Debug {
 				log.Infof("key: %s wakeup exit dispatch goroutine", ch.Key)
 			}
@@ -340,14 +333,9 @@ func (s *Server) dispatchWebsocket(ws *websocket.Conn, wp *bytes.Pool, wb *bytes
 				if p, err = ch.CliProto.Get(); err != nil {
 					break
 				}
-				if white {
-					whitelist.Printf("key: %s start write client proto%v\n", ch.Key, p)
-				}
 				if p.Op == protocol.OpHeartbeatReply {
-					if ch.Room != nil {
-						online = ch.Room.OnlineNum()
-					}
-					if err = p.WriteWebsocketHeart(ws, online); err != nil {
+					online, rooms = ch.Online()
+					if err = p.WriteWebsocketHeart(ws, online, rooms); err != nil {
 						goto failed
 					}
 				} else {
@@ -355,42 +343,43 @@ func (s *Server) dispatchWebsocket(ws *websocket.Conn, wp *bytes.Pool, wb *bytes
 						goto failed
 					}
 				}
-				if white {
-					whitelist.Printf("key: %s write client proto%v\n", ch.Key, p)
-				}
+				s.debug.Printf(ch, DebugReply, "proto: %v", p)
 				p.Body = nil // avoid memory leak
 				ch.CliProto.GetAdv()
 			}
 		default:
-			if white {
-				whitelist.Printf("key: %s start write server proto%v\n", ch.Key, p)
+			if gap, p = s.stamp(ch, p); gap != nil {
+				if err = gap.WriteWebsocket(ws); err != nil {
+					goto failed
+				}
 			}
-			if err = p.WriteWebsocket(ws); err != nil {
+			span := traceDispatch(ch, p)
+			err = p.WriteWebsocket(ws)
+			span.Finish(err)
+			if err != nil {
 				goto failed
 			}
-			if white {
-				whitelist.Printf("key: %s write server proto%v\n", ch.Key, p)
-			}
+			s.debug.Printf(ch, DebugPush, "proto: %v", p)
 			if conf.Conf.Debug {
 				log.Infof("websocket sent a message key:%s mid:%d proto:%+v", ch.Key, ch.Mid, p)
 			}
-		}
-		if white {
-			whitelist.Printf("key: %s start flush \n", ch.Key)
+			if p.Op == protocol.OpDisconnectReply {
+				// server draining, close after the reply flushed
+				err = ws.Flush()
+				goto failed
+			}
 		}
 		// only hungry flush response
+		s.setWriteDeadline(conn, time.Duration(s.c.Protocol.FlushTimeout))
 		if err = ws.Flush(); err != nil {
 			break
 		}
-		if white {
-			whitelist.Printf("key: %s flush\n", ch.Key)
-		}
+		s.debug.Printf(ch, DebugFlush, "flushed")
 	}
 failed:
-	if white {
-		whitelist.Printf("key: %s dispatch tcp error(%v)\n", ch.Key, err)
-	}
+	s.debug.Printf(ch, DebugDisconnect, "dispatch error(%v)", err)
 	if err != nil && err != io.EOF && err != websocket.ErrMessageClose {
+		s.cullStalled(ch, err)
 		log.Errorf("key: %s dispatch ws error(%v)", ch.Key, err)
 	}
 	ws.Close()
@@ -405,7 +394,7 @@ failed:
 }
 
 // auth for goim handshake with client, use rsa & aes.
-func (s *Server) authWebsocket(ctx context.Context, ws *websocket.Conn, p *protocol.Proto, cookie string) (mid int64, key, rid string, accepts []int32, hb time.Duration, err error) {
+func (s *Server) authWebsocket(ctx context.Context, ws *websocket.Conn, p *protocol.Proto, cookie string, ch *Channel) (mid int64, key, rid string, accepts []int32, hb time.Duration, expire int64, err error) {
 	for {
 		if err = p.ReadWebsocket(ws); err != nil {
 			return
@@ -416,7 +405,12 @@ func (s *Server) authWebsocket(ctx context.Context, ws *websocket.Conn, p *proto
 			log.Errorf("ws request operation(%d) not auth", p.Op)
 		}
 	}
-	if mid, key, rid, accepts, hb, err = s.Connect(ctx, p, cookie); err != nil {
+	if mid, key, rid, accepts, hb, expire, err = s.Connect(ctx, p, cookie, ch); err != nil {
+		// reply the failure before close
+		authFailed(p, err)
+		if p.WriteWebsocket(ws) == nil {
+			_ = ws.Flush()
+		}
 		return
 	}
 	p.Op = protocol.OpAuthReply
@@ -0,0 +1,68 @@
+package comet
+
+import (
+	"sync"
+
+	log "github.com/golang/glog"
+)
+
+// slow-consumer policies when the channel signal is full.
+const (
+	// SlowDropNewest drop the pushing message.
+	SlowDropNewest = "drop-newest"
+	// SlowDropOldest drop the oldest pending message.
+	SlowDropOldest = "drop-oldest"
+	// SlowDisconnect disconnect the slow client.
+	SlowDisconnect = "disconnect"
+	// SlowOverflow spill to a bounded per-channel overflow buffer.
+	SlowOverflow = "overflow"
+)
+
+// Slow is the slow-consumer policy of the channel pushes, it also counts
+// the dropped messages per op.
+type Slow struct {
+	policy   string
+	overflow int
+
+	mutex sync.Mutex
+	drops map[int32]uint64
+}
+
+// NewSlow new a slow-consumer policy, unknown policy falls back to drop-newest.
+func NewSlow(policy string, overflow int) *Slow {
+	switch policy {
+	case SlowDropNewest, SlowDropOldest, SlowDisconnect, SlowOverflow:
+	default:
+		if policy != "" {
+			log.Errorf("unknown slow-consumer policy: %s, use %s", policy, SlowDropNewest)
+		}
+		policy = SlowDropNewest
+	}
+	return &Slow{policy: policy, overflow: overflow, drops: make(map[int32]uint64)}
+}
+
+// Drop count the dropped messages of the op.
+func (s *Slow) Drop(op int32, n int) {
+	s.mutex.Lock()
+	s.drops[op] += uint64(n)
+	s.mutex.Unlock()
+}
+
+// Drops get the dropped message counts per op.
+func (s *Slow) Drops() (res map[int32]uint64) {
+	s.mutex.Lock()
+	res = make(map[int32]uint64, len(s.drops))
+	for op, n := range s.drops {
+		res[op] = n
+	}
+	s.mutex.Unlock()
+	return
+}
+
+// closerFunc adapts a func as an io.Closer.
+type closerFunc func()
+
+func (f closerFunc) Close() error {
+	f()
+	return nil
+}
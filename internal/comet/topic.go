@@ -0,0 +1,24 @@
+package comet
+
+import (
+	"strings"
+
+	"github.com/Terry-Mao/goim/api/protocol"
+)
+
+// splitTopics split the comma separated topics of the body.
+func splitTopics(body []byte) (topics []string) {
+	for _, topic := range strings.Split(string(body), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return
+}
+
+// PushTopic push a message to the subscribed channels of the topic.
+func (s *Server) PushTopic(topic string, p *protocol.Proto) {
+	for _, b := range s.buckets {
+		b.PushTopic(topic, p)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"time"
 
 	"github.com/Terry-Mao/goim/api/comet"
+	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/job/conf"
+	"github.com/Terry-Mao/goim/pkg/trace"
 	"github.com/bilibili/discovery/naming"
 
 	log "github.com/golang/glog"
@@ -23,6 +25,9 @@ var (
 	grpcBackoffMaxDelay  = time.Duration(3) * time.Second
 	grpcMaxSendMsgSize   = 1 << 24
 	grpcMaxCallMsgSize   = 1 << 24
+
+	// kick rpc timeout, a stalled comet never holds its kick queue
+	kickTimeout = time.Duration(3) * time.Second
 )
 
 const (
@@ -61,9 +66,12 @@ type Comet struct {
 	client        comet.CometClient
 	pushChan      []chan *comet.PushMsgReq
 	roomChan      []chan *comet.BroadcastRoomReq
+	topicChan     []chan *comet.PushTopicReq
 	broadcastChan chan *comet.BroadcastReq
+	kickChan      chan interface{} // *comet.KickKeysReq, *comet.KickRoomReq or *comet.KickIPReq
 	pushChanNum   uint64
 	roomChanNum   uint64
+	topicChanNum  uint64
 	routineSize   uint64
 
 	ctx    context.Context
@@ -76,7 +84,9 @@ func NewComet(in *naming.Instance, c *conf.Comet) (*Comet, error) {
 		serverID:      in.Hostname,
 		pushChan:      make([]chan *comet.PushMsgReq, c.RoutineSize),
 		roomChan:      make([]chan *comet.BroadcastRoomReq, c.RoutineSize),
+		topicChan:     make([]chan *comet.PushTopicReq, c.RoutineSize),
 		broadcastChan: make(chan *comet.BroadcastReq, c.RoutineSize),
+		kickChan:      make(chan interface{}, c.RoutineChan),
 		routineSize:   uint64(c.RoutineSize),
 	}
 	var grpcAddr string
@@ -98,7 +108,8 @@ func NewComet(in *naming.Instance, c *conf.Comet) (*Comet, error) {
 	for i := 0; i < c.RoutineSize; i++ {
 		cmt.pushChan[i] = make(chan *comet.PushMsgReq, c.RoutineChan)
 		cmt.roomChan[i] = make(chan *comet.BroadcastRoomReq, c.RoutineChan)
-		go cmt.process(cmt.pushChan[i], cmt.roomChan[i], cmt.broadcastChan)
+		cmt.topicChan[i] = make(chan *comet.PushTopicReq, c.RoutineChan)
+		go cmt.process(cmt.pushChan[i], cmt.roomChan[i], cmt.topicChan[i], cmt.broadcastChan)
 	}
 	return cmt, nil
 }
@@ -117,41 +128,125 @@ func (c *Comet) BroadcastRoom(arg *comet.BroadcastRoomReq) (err error) {
 	return
 }
 
+// PushTopic push a topic message.
+func (c *Comet) PushTopic(arg *comet.PushTopicReq) (err error) {
+	idx := atomic.AddUint64(&c.topicChanNum, 1) % c.routineSize
+	c.topicChan[idx] <- arg
+	return
+}
+
 // Broadcast broadcast a message.
 func (c *Comet) Broadcast(arg *comet.BroadcastReq) (err error) {
 	c.broadcastChan <- arg
 	return
 }
 
-func (c *Comet) process(pushChan chan *comet.PushMsgReq, roomChan chan *comet.BroadcastRoomReq, broadcastChan chan *comet.BroadcastReq) {
+// KickKeys disconnect the keys.
+func (c *Comet) KickKeys(arg *comet.KickKeysReq) (err error) {
+	c.kickChan <- arg
+	return
+}
+
+// KickRoom disconnect all in the room.
+func (c *Comet) KickRoom(arg *comet.KickRoomReq) (err error) {
+	c.kickChan <- arg
+	return
+}
+
+// KickIP disconnect all of the ip.
+func (c *Comet) KickIP(arg *comet.KickIPReq) (err error) {
+	c.kickChan <- arg
+	return
+}
+
+// kick call the kick rpc with a timeout.
+func (c *Comet) kick(arg interface{}) {
+	ctx, cancel := context.WithTimeout(c.ctx, kickTimeout)
+	defer cancel()
+	var err error
+	switch arg := arg.(type) {
+	case *comet.KickKeysReq:
+		_, err = c.client.KickKeys(ctx, arg)
+	case *comet.KickRoomReq:
+		_, err = c.client.KickRoom(ctx, arg)
+	case *comet.KickIPReq:
+		_, err = c.client.KickIP(ctx, arg)
+	}
+	if err != nil {
+		log.Errorf("c.client.Kick(%v) serverId:%s error(%v)", arg, c.serverID, err)
+	}
+}
+
+// Queues get the pending requests of the push, room, topic and broadcast
+// queues.
+func (c *Comet) Queues() (push, room, topic, broadcast int) {
+	for i := range c.pushChan {
+		push += len(c.pushChan[i])
+		room += len(c.roomChan[i])
+		topic += len(c.topicChan[i])
+	}
+	broadcast = len(c.broadcastChan)
+	return
+}
+
+// startCall start the client span of the rpc if the proto is traced, the
+// proto may be shared by the comets, so the traced one is a copy.
+func (c *Comet) startCall(method string, p *protocol.Proto) (*trace.Span, *protocol.Proto) {
+	span := trace.Start("comet."+method, trace.KindClient, p.Trace)
+	if span == nil {
+		return nil, p
+	}
+	span.SetAttr("server", c.serverID)
+	return span, &protocol.Proto{Ver: p.Ver, Op: p.Op, Seq: p.Seq, Body: p.Body, Trace: span.Traceparent()}
+}
+
+func (c *Comet) process(pushChan chan *comet.PushMsgReq, roomChan chan *comet.BroadcastRoomReq, topicChan chan *comet.PushTopicReq, broadcastChan chan *comet.BroadcastReq) {
 	for {
 		select {
 		case broadcastArg := <-broadcastChan:
+			span, p := c.startCall("Broadcast", broadcastArg.Proto)
 			_, err := c.client.Broadcast(context.Background(), &comet.BroadcastReq{
-				Proto:   broadcastArg.Proto,
+				Proto:   p,
 				ProtoOp: broadcastArg.ProtoOp,
 				Speed:   broadcastArg.Speed,
 			})
 			if err != nil {
 				log.Errorf("c.client.Broadcast(%s, reply) serverId:%s error(%v)", broadcastArg, c.serverID, err)
 			}
+			span.Finish(err)
 		case roomArg := <-roomChan:
+			span, p := c.startCall("BroadcastRoom", roomArg.Proto)
 			_, err := c.client.BroadcastRoom(context.Background(), &comet.BroadcastRoomReq{
 				RoomID: roomArg.RoomID,
-				Proto:  roomArg.Proto,
+				Proto:  p,
 			})
 			if err != nil {
 				log.Errorf("c.client.BroadcastRoom(%s, reply) serverId:%s error(%v)", roomArg, c.serverID, err)
 			}
+			span.Finish(err)
+		case topicArg := <-topicChan:
+			span, p := c.startCall("PushTopic", topicArg.Proto)
+			_, err := c.client.PushTopic(context.Background(), &comet.PushTopicReq{
+				Topic: topicArg.Topic,
+				Proto: p,
+			})
+			if err != nil {
+				log.Errorf("c.client.PushTopic(%s, reply) serverId:%s error(%v)", topicArg, c.serverID, err)
+			}
+			span.Finish(err)
 		case pushArg := <-pushChan:
+			span, p := c.startCall("PushMsg", pushArg.Proto)
 			_, err := c.client.PushMsg(context.Background(), &comet.PushMsgReq{
 				Keys:    pushArg.Keys,
-				Proto:   pushArg.Proto,
+				Proto:   p,
 				ProtoOp: pushArg.ProtoOp,
 			})
 			if err != nil {
 				log.Errorf("c.client.PushMsg(%s, reply) serverId:%s error(%v)", pushArg, c.serverID, err)
 			}
+			span.Finish(err)
+		case kickArg := <-c.kickChan:
+			c.kick(kickArg)
 		case <-c.ctx.Done():
 			return
 		}
@@ -163,13 +258,16 @@ func (c *Comet) Close() (err error) {
 	finish := make(chan bool)
 	go func() {
 		for {
-			n := len(c.broadcastChan)
+			n := len(c.broadcastChan) + len(c.kickChan)
 			for _, ch := range c.pushChan {
 				n += len(ch)
 			}
 			for _, ch := range c.roomChan {
 				n += len(ch)
 			}
+			for _, ch := range c.topicChan {
+				n += len(ch)
+			}
 			if n == 0 {
 				finish <- true
 				return
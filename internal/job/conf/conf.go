@@ -8,6 +8,7 @@ import (
 	"github.com/bilibili/discovery/naming"
 	"github.com/BurntSushi/toml"
 	xtime "github.com/Terry-Mao/goim/pkg/time"
+	"github.com/Terry-Mao/goim/pkg/trace"
 )
 
 var (
@@ -44,6 +45,12 @@ func Default() *Config {
 		Env:       &Env{Region: region, Zone: zone, DeployEnv: deployEnv, Host: host},
 		Discovery: &naming.Config{Region: region, Zone: zone, Env: deployEnv, Host: host},
 		Comet:     &Comet{RoutineChan: 1024, RoutineSize: 32},
+		HTTPServer: &HTTPServer{
+			Network:      "tcp",
+			Addr:         ":3112",
+			ReadTimeout:  xtime.Duration(time.Second),
+			WriteTimeout: xtime.Duration(time.Second),
+		},
 		Room: &Room{
 			Batch:  20,
 			Signal: xtime.Duration(time.Second),
@@ -59,6 +66,17 @@ type Config struct {
 	Discovery *naming.Config
 	Comet     *Comet
 	Room      *Room
+	// HTTPServer serves the metrics
+	HTTPServer *HTTPServer
+	Trace      *trace.Config
+}
+
+// HTTPServer is http server config.
+type HTTPServer struct {
+	Network      string
+	Addr         string
+	ReadTimeout  xtime.Duration
+	WriteTimeout xtime.Duration
 }
 
 // Room is room config.
@@ -3,11 +3,13 @@ package job
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
 	pb "github.com/Terry-Mao/goim/api/logic"
 	"github.com/Terry-Mao/goim/internal/job/conf"
+	"github.com/Terry-Mao/goim/pkg/trace"
 	"github.com/bilibili/discovery/naming"
 	"github.com/golang/protobuf/proto"
 
@@ -23,6 +25,10 @@ type Job struct {
 
 	rooms      map[string]*Room
 	roomsMutex sync.RWMutex
+
+	// consumed offsets per claimed topic and partition
+	offsets      map[string]map[int32]int64
+	offsetsMutex sync.Mutex
 }
 
 // New new a push job.
@@ -31,6 +37,7 @@ func New(c *conf.Config) *Job {
 		c:        c,
 		consumer: newKafkaSub(c.Kafka),
 		rooms:    make(map[string]*Room),
+		offsets:  make(map[string]map[int32]int64),
 	}
 	j.watchComet(c.Discovery)
 	return j
@@ -63,25 +70,72 @@ func (j *Job) Consume() {
 			log.Errorf("consumer error(%v)", err)
 		case n := <-j.consumer.Notifications():
 			log.Infof("consumer rebalanced(%v)", n)
+			if n != nil {
+				j.rebalanced(n.Current)
+			}
 		case msg, ok := <-j.consumer.Messages():
 			if !ok {
 				return
 			}
 			j.consumer.MarkOffset(msg, "")
+			j.consumed(msg.Topic, msg.Partition, msg.Offset)
 			// process push message
 			pushMsg := new(pb.PushMsg)
 			if err := proto.Unmarshal(msg.Value, pushMsg); err != nil {
+				messageFailures.WithLabelValues("unknown").Inc()
 				log.Errorf("proto.Unmarshal(%v) error(%v)", msg, err)
 				continue
 			}
-			if err := j.push(context.Background(), pushMsg); err != nil {
+			typ := strings.ToLower(pushMsg.Type.String())
+			messages.WithLabelValues(typ).Inc()
+			span := trace.Start("job.push", trace.KindConsumer, pushMsg.Trace)
+			span.SetAttr("type", typ)
+			span.SetAttr("partition", msg.Partition)
+			span.SetAttr("offset", msg.Offset)
+			err := j.push(trace.NewContext(context.Background(), span), pushMsg)
+			if err != nil {
+				messageFailures.WithLabelValues(typ).Inc()
 				log.Errorf("j.push(%v) error(%v)", pushMsg, err)
 			}
+			span.Finish(err)
 			log.Infof("consume: %s/%d/%d\t%s\t%+v", msg.Topic, msg.Partition, msg.Offset, msg.Key, pushMsg)
 		}
 	}
 }
 
+// consumed save the consumed offset of the partition for the lag.
+func (j *Job) consumed(topic string, partition int32, offset int64) {
+	j.offsetsMutex.Lock()
+	offsets, ok := j.offsets[topic]
+	if !ok {
+		offsets = make(map[int32]int64)
+		j.offsets[topic] = offsets
+	}
+	offsets[partition] = offset
+	j.offsetsMutex.Unlock()
+}
+
+// rebalanced drop the offsets of the partitions no longer claimed, so the
+// lag is only reported for the current partitions.
+func (j *Job) rebalanced(current map[string][]int32) {
+	j.offsetsMutex.Lock()
+	for topic, offsets := range j.offsets {
+		claimed := make(map[int32]struct{}, len(current[topic]))
+		for _, partition := range current[topic] {
+			claimed[partition] = struct{}{}
+		}
+		for partition := range offsets {
+			if _, ok := claimed[partition]; !ok {
+				delete(offsets, partition)
+			}
+		}
+		if len(offsets) == 0 {
+			delete(j.offsets, topic)
+		}
+	}
+	j.offsetsMutex.Unlock()
+}
+
 func (j *Job) watchComet(c *naming.Config) {
 	dis := naming.New(c)
 	resolver := dis.Build("goim.comet")
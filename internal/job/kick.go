@@ -0,0 +1,37 @@
+package job
+
+import (
+	"github.com/Terry-Mao/goim/api/comet"
+	log "github.com/golang/glog"
+)
+
+// kick disconnect the keys of the server, or all in the room or of the ip
+// on every comet, the kicks are queued to the comets like the pushes.
+func (j *Job) kick(serverID string, keys []string, room, ip, reason string) (err error) {
+	comets := j.cometServers
+	switch {
+	case len(keys) > 0:
+		if c, ok := comets[serverID]; ok {
+			arg := &comet.KickKeysReq{Keys: keys, Reason: reason}
+			if err = c.KickKeys(arg); err != nil {
+				log.Errorf("c.KickKeys(%v) serverID:%s error(%v)", arg, serverID, err)
+			}
+		}
+	case room != "":
+		arg := &comet.KickRoomReq{RoomID: room, Reason: reason}
+		for serverID, c := range comets {
+			if err = c.KickRoom(arg); err != nil {
+				log.Errorf("c.KickRoom(%v) serverID:%s error(%v)", arg, serverID, err)
+			}
+		}
+	case ip != "":
+		arg := &comet.KickIPReq{Ip: ip, Reason: reason}
+		for serverID, c := range comets {
+			if err = c.KickIP(arg); err != nil {
+				log.Errorf("c.KickIP(%v) serverID:%s error(%v)", arg, serverID, err)
+			}
+		}
+	}
+	log.Infof("kick server:%s keys:%v room:%s ip:%s reason:%s", serverID, keys, room, ip, reason)
+	return
+}
@@ -0,0 +1,103 @@
+package job
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/job/conf"
+	log "github.com/golang/glog"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	messages = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "goim",
+		Subsystem: "job",
+		Name:      "messages_total",
+		Help:      "Consumed messages per type.",
+	}, []string{"type"})
+	messageFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "goim",
+		Subsystem: "job",
+		Name:      "message_failures_total",
+		Help:      "Failed messages per type.",
+	}, []string{"type"})
+
+	lagDesc = prometheus.NewDesc("goim_job_consumer_lag",
+		"Messages behind the high water mark per partition.", []string{"topic", "partition"}, nil)
+	queueDesc = prometheus.NewDesc("goim_job_comet_queue_depth",
+		"Pending requests per comet and queue.", []string{"comet", "queue"}, nil)
+	roomsDesc = prometheus.NewDesc("goim_job_rooms",
+		"Active room goroutines.", nil, nil)
+)
+
+func init() {
+	prometheus.MustRegister(messages, messageFailures)
+}
+
+// jobCollector collects the consumer lag, the comet queue depth and the
+// active rooms when scraped.
+type jobCollector struct {
+	job *Job
+}
+
+func (c *jobCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- lagDesc
+	ch <- queueDesc
+	ch <- roomsDesc
+}
+
+func (c *jobCollector) Collect(ch chan<- prometheus.Metric) {
+	j := c.job
+	hwms := j.consumer.HighWaterMarks()
+	j.offsetsMutex.Lock()
+	for topic, offsets := range j.offsets {
+		for partition, offset := range offsets {
+			if hwm, ok := hwms[topic][partition]; ok {
+				ch <- prometheus.MustNewConstMetric(lagDesc, prometheus.GaugeValue, float64(hwm-offset-1), topic, strconv.Itoa(int(partition)))
+			}
+		}
+	}
+	j.offsetsMutex.Unlock()
+	for server, cmt := range j.cometServers {
+		push, room, topic, broadcast := cmt.Queues()
+		ch <- prometheus.MustNewConstMetric(queueDesc, prometheus.GaugeValue, float64(push), server, "push")
+		ch <- prometheus.MustNewConstMetric(queueDesc, prometheus.GaugeValue, float64(room), server, "room")
+		ch <- prometheus.MustNewConstMetric(queueDesc, prometheus.GaugeValue, float64(topic), server, "topic")
+		ch <- prometheus.MustNewConstMetric(queueDesc, prometheus.GaugeValue, float64(broadcast), server, "broadcast")
+	}
+	j.roomsMutex.RLock()
+	rooms := len(j.rooms)
+	j.roomsMutex.RUnlock()
+	ch <- prometheus.MustNewConstMetric(roomsDesc, prometheus.GaugeValue, float64(rooms))
+}
+
+// InitHTTP listen the http server and serve the metrics at /metrics.
+func InitHTTP(j *Job, c *conf.HTTPServer) (err error) {
+	var listener net.Listener
+	if err = prometheus.Register(&jobCollector{job: j}); err != nil {
+		log.Errorf("prometheus.Register() error(%v)", err)
+		return
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	if listener, err = net.Listen(c.Network, c.Addr); err != nil {
+		log.Errorf("net.Listen(%s, %s) error(%v)", c.Network, c.Addr, err)
+		return
+	}
+	log.Infof("start http listen: %s", c.Addr)
+	srv := &http.Server{
+		Handler:      mux,
+		ReadTimeout:  time.Duration(c.ReadTimeout),
+		WriteTimeout: time.Duration(c.WriteTimeout),
+	}
+	go func() {
+		if err := srv.Serve(listener); err != nil {
+			log.Errorf("http serve(%s) error(%v)", c.Addr, err)
+		}
+	}()
+	return
+}
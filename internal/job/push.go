@@ -8,34 +8,61 @@ import (
 	pb "github.com/Terry-Mao/goim/api/logic"
 	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/pkg/bytes"
+	"github.com/Terry-Mao/goim/pkg/trace"
 	log "github.com/golang/glog"
 )
 
 func (j *Job) push(ctx context.Context, pushMsg *pb.PushMsg) (err error) {
+	// traceparent of the job span, empty if not traced
+	tp := trace.FromContext(ctx).Traceparent()
 	switch pushMsg.Type {
 	case pb.PushMsg_PUSH:
-		err = j.pushKeys(pushMsg.Operation, pushMsg.Server, pushMsg.Keys, pushMsg.Msg)
+		err = j.pushSeqKeys(pushMsg.Operation, pushMsg.Seqs, pushMsg.Server, pushMsg.Keys, pushMsg.Msg, tp)
 	case pb.PushMsg_ROOM:
-		err = j.getRoom(pushMsg.Room).Push(pushMsg.Operation, pushMsg.Msg)
+		err = j.getRoom(pushMsg.Room).Push(pushMsg.Operation, pushMsg.Msg, tp)
 	case pb.PushMsg_BROADCAST:
-		err = j.broadcast(pushMsg.Operation, pushMsg.Msg, pushMsg.Speed)
+		err = j.broadcast(pushMsg.Operation, pushMsg.Msg, pushMsg.Speed, tp)
+	case pb.PushMsg_TOPIC:
+		err = j.broadcastTopic(pushMsg.Operation, pushMsg.Topic, pushMsg.Msg, tp)
+	case pb.PushMsg_KICK:
+		err = j.kick(pushMsg.Server, pushMsg.Keys, pushMsg.Room, pushMsg.Ip, string(pushMsg.Msg))
 	default:
 		err = fmt.Errorf("no match push type: %s", pushMsg.Type)
 	}
 	return
 }
 
+// pushSeqKeys push a message to a batch of subkeys, the keys of the same
+// inbox seq are pushed together if seqs given.
+func (j *Job) pushSeqKeys(operation int32, seqs []int32, serverID string, subKeys []string, body []byte, tp string) (err error) {
+	if len(seqs) != len(subKeys) {
+		return j.pushKeys(operation, 0, serverID, subKeys, body, tp)
+	}
+	seqKeys := make(map[int32][]string)
+	for i, key := range subKeys {
+		seqKeys[seqs[i]] = append(seqKeys[seqs[i]], key)
+	}
+	for seq, keys := range seqKeys {
+		if err = j.pushKeys(operation, seq, serverID, keys, body, tp); err != nil {
+			return
+		}
+	}
+	return
+}
+
 // pushKeys push a message to a batch of subkeys.
-func (j *Job) pushKeys(operation int32, serverID string, subKeys []string, body []byte) (err error) {
+func (j *Job) pushKeys(operation, seq int32, serverID string, subKeys []string, body []byte, tp string) (err error) {
 	buf := bytes.NewWriterSize(len(body) + 64)
 	p := &protocol.Proto{
 		Ver:  1,
 		Op:   operation,
+		Seq:  seq,
 		Body: body,
 	}
 	p.WriteTo(buf)
 	p.Body = buf.Buffer()
 	p.Op = protocol.OpRaw
+	p.Trace = tp
 	var args = comet.PushMsgReq{
 		Keys:    subKeys,
 		ProtoOp: operation,
@@ -51,7 +78,7 @@ func (j *Job) pushKeys(operation int32, serverID string, subKeys []string, body
 }
 
 // broadcast broadcast a message to all.
-func (j *Job) broadcast(operation int32, body []byte, speed int32) (err error) {
+func (j *Job) broadcast(operation int32, body []byte, speed int32, tp string) (err error) {
 	buf := bytes.NewWriterSize(len(body) + 64)
 	p := &protocol.Proto{
 		Ver:  1,
@@ -61,6 +88,7 @@ func (j *Job) broadcast(operation int32, body []byte, speed int32) (err error) {
 	p.WriteTo(buf)
 	p.Body = buf.Buffer()
 	p.Op = protocol.OpRaw
+	p.Trace = tp
 	comets := j.cometServers
 	speed /= int32(len(comets))
 	var args = comet.BroadcastReq{
@@ -77,14 +105,42 @@ func (j *Job) broadcast(operation int32, body []byte, speed int32) (err error) {
 	return
 }
 
-// broadcastRoomRawBytes broadcast aggregation messages to room.
-func (j *Job) broadcastRoomRawBytes(roomID string, body []byte) (err error) {
+// broadcastTopic broadcast a message to the subscribers of the topic.
+func (j *Job) broadcastTopic(operation int32, topic string, body []byte, tp string) (err error) {
+	buf := bytes.NewWriterSize(len(body) + 64)
+	p := &protocol.Proto{
+		Ver:  1,
+		Op:   operation,
+		Body: body,
+	}
+	p.WriteTo(buf)
+	p.Body = buf.Buffer()
+	p.Op = protocol.OpRaw
+	p.Trace = tp
+	args := comet.PushTopicReq{
+		Topic: topic,
+		Proto: p,
+	}
+	comets := j.cometServers
+	for serverID, c := range comets {
+		if err = c.PushTopic(&args); err != nil {
+			log.Errorf("c.PushTopic(%v) topic:%s serverID:%s error(%v)", args, topic, serverID, err)
+		}
+	}
+	log.Infof("broadcastTopic comets:%d", len(comets))
+	return
+}
+
+// broadcastRoomRawBytes broadcast aggregation messages to room, the batch
+// is traced by the traceparent of a message in it.
+func (j *Job) broadcastRoomRawBytes(roomID string, body []byte, tp string) (err error) {
 	args := comet.BroadcastRoomReq{
 		RoomID: roomID,
 		Proto: &protocol.Proto{
-			Ver:  1,
-			Op:   protocol.OpRaw,
-			Body: body,
+			Ver:   1,
+			Op:    protocol.OpRaw,
+			Body:  body,
+			Trace: tp,
 		},
 	}
 	comets := j.cometServers
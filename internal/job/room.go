@@ -42,11 +42,12 @@ func NewRoom(job *Job, id string, c *conf.Room) (r *Room) {
 }
 
 // Push push msg to the room, if chan full discard it.
-func (r *Room) Push(op int32, msg []byte) (err error) {
+func (r *Room) Push(op int32, msg []byte, tp string) (err error) {
 	var p = &protocol.Proto{
-		Ver:  1,
-		Op:   op,
-		Body: msg,
+		Ver:   1,
+		Op:    op,
+		Body:  msg,
+		Trace: tp,
 	}
 	select {
 	case r.proto <- p:
@@ -62,6 +63,7 @@ func (r *Room) pushproc(batch int, sigTime time.Duration) {
 		n    int
 		last time.Time
 		p    *protocol.Proto
+		tp   string // traceparent of the batch
 		buf  = bytes.NewWriterSize(int(protocol.MaxBodySize))
 	)
 	log.Infof("start room:%s goroutine", r.id)
@@ -78,6 +80,9 @@ func (r *Room) pushproc(batch int, sigTime time.Duration) {
 		} else if p != roomReadyProto {
 			// merge buffer ignore error, always nil
 			p.WriteTo(buf)
+			if tp == "" {
+				tp = p.Trace
+			}
 			if n++; n == 1 {
 				last = time.Now()
 				td.Reset(sigTime)
@@ -92,11 +97,12 @@ func (r *Room) pushproc(batch int, sigTime time.Duration) {
 				break
 			}
 		}
-		_ = r.job.broadcastRoomRawBytes(r.id, buf.Buffer())
+		_ = r.job.broadcastRoomRawBytes(r.id, buf.Buffer(), tp)
 		// TODO use reset buffer
 		// after push to room channel, renew a buffer, let old buffer gc
 		buf = bytes.NewWriterSize(buf.Size())
 		n = 0
+		tp = ""
 		if r.c.Idle != 0 {
 			td.Reset(time.Duration(r.c.Idle))
 		} else {
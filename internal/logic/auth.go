@@ -0,0 +1,86 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	"github.com/Terry-Mao/goim/pkg/jwt"
+	log "github.com/golang/glog"
+)
+
+// ErrUnauthorized the token is not valid.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// authParams is the claims of the token.
+type authParams struct {
+	Mid      int64   `json:"mid"`
+	Key      string  `json:"key"`
+	RoomID   string  `json:"room_id"`
+	Platform string  `json:"platform"`
+	Accepts  []int32 `json:"accepts"`
+	// heartbeat interval in seconds, default node heartbeat if zero
+	Heartbeat int64 `json:"heartbeat"`
+	// session expiry unix seconds, never expires if zero
+	Expire float64 `json:"exp"`
+	// allow-lists of the rooms, the ops and the topics to subscribe
+	Rooms  []string `json:"rooms"`
+	Ops    []int32  `json:"ops"`
+	Topics []string `json:"topics"`
+}
+
+func newVerifier(c *conf.Auth) *jwt.Verifier {
+	if c == nil || !c.Open {
+		return nil
+	}
+	v, err := jwt.NewVerifier(&jwt.Config{
+		Issuer:     c.Issuer,
+		Audience:   c.Audience,
+		Algs:       c.Algs,
+		Leeway:     time.Duration(c.Leeway),
+		MaxAge:     time.Duration(c.MaxAge),
+		RequireExp: c.RequireExp,
+	}, c.KeyFiles)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// auth delegate to the webhook if open, or verify the token and decode the
+// claims, the plain json token is trusted if the signed token verification
+// is not open.
+func (l *Logic) auth(c context.Context, server, ip, cookie string, token []byte, params *authParams) (err error) {
+	if l.webhook != nil {
+		if err = l.webhook.auth(c, server, ip, cookie, token, params); err != errWebhookUnavailable {
+			return
+		}
+		// never fail open to the plain json token
+		if !l.c.Webhook.FailOpen || l.verifier == nil {
+			return
+		}
+		log.Warningf("auth webhook unavailable, fail open to verify token")
+	}
+	if l.verifier == nil {
+		if err = json.Unmarshal(token, params); err != nil {
+			log.Errorf("json.Unmarshal(%s) error(%v)", token, err)
+			return ErrUnauthorized
+		}
+		return
+	}
+	if err = l.verifier.Verify(string(token), params); err != nil {
+		log.Errorf("verifier.Verify(%s) error(%v)", token, err)
+		return ErrUnauthorized
+	}
+	return
+}
+
+// ReloadAuthKeys reload the token verification keys from disk.
+func (l *Logic) ReloadAuthKeys() (err error) {
+	if l.verifier == nil {
+		return
+	}
+	return l.verifier.Reload()
+}
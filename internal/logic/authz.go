@@ -0,0 +1,72 @@
+package logic
+
+import (
+	"context"
+	"strings"
+
+	"github.com/Terry-Mao/goim/internal/logic/model"
+	log "github.com/golang/glog"
+)
+
+// Authorize check if the mid may enter the room and subscribe the ops and the
+// topics, an empty room is not checked. The public rooms, ops and topics are
+// allowed, the other rooms need the mid in the room acl and the other ops and
+// topics are only allowed by the token claims.
+func (l *Logic) Authorize(c context.Context, mid int64, key, roomID string, ops []int32, topics []string) (allow bool, err error) {
+	if l.c.Authz == nil || !l.c.Authz.Open {
+		return true, nil
+	}
+	for _, op := range ops {
+		if !l.publicOp(op) {
+			log.Warningf("authorize key:%s mid:%d op:%d denied", key, mid, op)
+			return
+		}
+	}
+	for _, topic := range topics {
+		if !hasPrefix(topic, l.c.Authz.PublicTopics) {
+			log.Warningf("authorize key:%s mid:%d topic:%s denied", key, mid, topic)
+			return
+		}
+	}
+	if roomID == "" || hasPrefix(roomID, l.c.Authz.PublicRooms) {
+		return true, nil
+	}
+	if mid == 0 {
+		return
+	}
+	if allow, err = l.dao.ExistsRoomACL(c, roomID, mid); err != nil {
+		return
+	}
+	if !allow {
+		log.Warningf("authorize key:%s mid:%d room:%s denied", key, mid, roomID)
+	}
+	return
+}
+
+func hasPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *Logic) publicOp(op int32) bool {
+	for _, o := range l.c.Authz.PublicOps {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// AddRoomACL allow the mids to enter the room.
+func (l *Logic) AddRoomACL(c context.Context, typ, room string, mids []int64) (err error) {
+	return l.dao.AddRoomACL(c, model.EncodeRoomKey(typ, room), mids)
+}
+
+// DelRoomACL disallow the mids to enter the room.
+func (l *Logic) DelRoomACL(c context.Context, typ, room string, mids []int64) (err error) {
+	return l.dao.DelRoomACL(c, model.EncodeRoomKey(typ, room), mids)
+}
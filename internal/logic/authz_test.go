@@ -0,0 +1,32 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorize(t *testing.T) {
+	var (
+		c = context.Background()
+		l = &Logic{c: &conf.Config{Authz: &conf.Authz{}}}
+	)
+	allow, err := l.Authorize(c, 1, "key", "private://1", []int32{2000}, []string{"private:1"})
+	assert.Nil(t, err)
+	assert.True(t, allow)
+	l.c.Authz = &conf.Authz{Open: true, PublicRooms: []string{"live://"}, PublicOps: []int32{1000}, PublicTopics: []string{"stock:"}}
+	allow, err = l.Authorize(c, 1, "key", "live://1000", []int32{1000}, []string{"stock:AAPL"})
+	assert.Nil(t, err)
+	assert.True(t, allow)
+	allow, err = l.Authorize(c, 1, "key", "", []int32{1000, 2000}, nil)
+	assert.Nil(t, err)
+	assert.False(t, allow)
+	allow, err = l.Authorize(c, 0, "key", "private://1", nil, nil)
+	assert.Nil(t, err)
+	assert.False(t, allow)
+	allow, err = l.Authorize(c, 1, "key", "", nil, []string{"stock:AAPL", "match:1:score"})
+	assert.Nil(t, err)
+	assert.False(t, allow)
+}
@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/Terry-Mao/goim/api/logic"
+	"github.com/Terry-Mao/goim/api/protocol"
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	xtime "github.com/Terry-Mao/goim/pkg/time"
+	log "github.com/golang/glog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/balancer/roundrobin"
+)
+
+// backend is a business service receiving the upstream messages.
+type backend struct {
+	c      *conf.Backend
+	client pb.BackendClient
+}
+
+const _backendTimeout = time.Second
+
+// newBackends dial the backends, the timeout is 1s if not set.
+func newBackends(cs []*conf.Backend) (bs []*backend) {
+	for _, c := range cs {
+		if c.Timeout <= 0 {
+			c.Timeout = xtime.Duration(_backendTimeout)
+		}
+		conn, err := grpc.Dial(c.Target,
+			grpc.WithInsecure(),
+			grpc.WithBalancerName(roundrobin.Name),
+		)
+		if err != nil {
+			panic(err)
+		}
+		bs = append(bs, &backend{c: c, client: pb.NewBackendClient(conn)})
+	}
+	return
+}
+
+// backend get the backend routed by op, nil if not found.
+func (l *Logic) backend(op int32) *backend {
+	for _, b := range l.backends {
+		if op >= b.c.MinOp && op <= b.c.MaxOp {
+			return b
+		}
+	}
+	return nil
+}
+
+// receiveBackend send the message to backend, push the reply body back to
+// the sender key if the backend replies.
+func (l *Logic) receiveBackend(c context.Context, b *backend, mid int64, key, server, room string, p *protocol.Proto) (code int32, msg string, err error) {
+	ctx, cancel := context.WithTimeout(c, time.Duration(b.c.Timeout))
+	defer cancel()
+	reply, err := b.client.Receive(ctx, &pb.ReceiveMsg{
+		Mid:       mid,
+		Key:       key,
+		Server:    server,
+		Room:      room,
+		Timestamp: time.Now().Unix(),
+		Proto:     p,
+	})
+	if err != nil {
+		log.Errorf("backend(%s).Receive(%d,%s) op:%d error(%v)", b.c.Name, mid, key, p.Op, err)
+		return
+	}
+	if b.c.Reply && len(reply.Body) > 0 {
+		op := reply.Op
+		if op == 0 {
+			op = p.Op
+		}
+		if err = l.dao.PushMsg(c, op, server, []string{key}, reply.Body); err != nil {
+			log.Errorf("l.dao.PushMsg(%d,%s,%s) error(%v)", op, server, key, err)
+			return
+		}
+	}
+	return reply.Code, reply.Msg, nil
+}
@@ -0,0 +1,28 @@
+package logic
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackend(t *testing.T) {
+	l := &Logic{
+		backends: []*backend{
+			{c: &conf.Backend{Name: "chat", MinOp: 1000, MaxOp: 1999}},
+			{c: &conf.Backend{Name: "gift", MinOp: 2000, MaxOp: 2000}},
+		},
+	}
+	assert.Equal(t, "chat", l.backend(1000).c.Name)
+	assert.Equal(t, "chat", l.backend(1999).c.Name)
+	assert.Equal(t, "gift", l.backend(2000).c.Name)
+	assert.Nil(t, l.backend(4))
+	assert.Nil(t, l.backend(2001))
+}
+
+func TestNewBackends(t *testing.T) {
+	bs := newBackends([]*conf.Backend{{Name: "chat", Target: "127.0.0.1:3131"}})
+	assert.Equal(t, _backendTimeout, time.Duration(bs[0].c.Timeout))
+}
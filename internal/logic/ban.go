@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/logic/model"
+	log "github.com/golang/glog"
+)
+
+var (
+	// ErrBanned the mid, key or ip is banned.
+	ErrBanned = errors.New("banned")
+	// ErrBanArg invalid ban type or value.
+	ErrBanArg = errors.New("invalid ban arg")
+)
+
+func newBan(typ, value string) (ban *model.Ban, err error) {
+	ban = &model.Ban{Type: typ, Value: value}
+	switch typ {
+	case model.BanMid:
+		if mid, e := strconv.ParseInt(value, 10, 64); e != nil || mid <= 0 {
+			return nil, ErrBanArg
+		}
+	case model.BanKey:
+		if value == "" {
+			return nil, ErrBanArg
+		}
+	case model.BanIP:
+		if strings.Contains(value, "/") {
+			if _, _, e := net.ParseCIDR(value); e != nil {
+				return nil, ErrBanArg
+			}
+		} else if net.ParseIP(value) == nil {
+			return nil, ErrBanArg
+		}
+	default:
+		return nil, ErrBanArg
+	}
+	return
+}
+
+// AddBan ban a mid, key or ip/cidr for the ttl, zero never expires, and kick
+// the live sessions of it.
+func (l *Logic) AddBan(c context.Context, typ, value, reason string, ttl time.Duration) (err error) {
+	ban, err := newBan(typ, value)
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	ban.Reason = reason
+	ban.Created = now.Unix()
+	if ttl > 0 {
+		ban.Expire = now.Add(ttl).Unix()
+	}
+	if err = l.dao.AddBan(c, ban); err != nil {
+		return
+	}
+	log.Infof("ban added type:%s value:%s reason:%s expire:%d", typ, value, reason, ban.Expire)
+	switch typ {
+	case model.BanMid:
+		mid, _ := strconv.ParseInt(value, 10, 64)
+		err = l.KickMids(c, []int64{mid}, reason)
+	case model.BanKey:
+		err = l.KickKeys(c, []string{value}, reason)
+	case model.BanIP:
+		err = l.KickIP(c, value, reason)
+	}
+	return
+}
+
+// DelBan delete a ban.
+func (l *Logic) DelBan(c context.Context, typ, value string) (err error) {
+	ban, err := newBan(typ, value)
+	if err != nil {
+		return
+	}
+	return l.dao.DelBan(c, ban)
+}
+
+// Bans get all the unexpired bans.
+func (l *Logic) Bans(c context.Context) (bans []*model.Ban, err error) {
+	return l.dao.Bans(c)
+}
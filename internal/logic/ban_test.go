@@ -0,0 +1,33 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/Terry-Mao/goim/internal/logic/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBan(t *testing.T) {
+	for _, tc := range []struct {
+		typ, value string
+		err        error
+	}{
+		{model.BanMid, "123", nil},
+		{model.BanMid, "abc", ErrBanArg},
+		{model.BanKey, "key", nil},
+		{model.BanIP, "10.0.0.1", nil},
+		{model.BanIP, "10.0.0.0/8", nil},
+		{model.BanIP, "10.0.0.0/33", ErrBanArg},
+		{"room", "live://1", ErrBanArg},
+	} {
+		_, err := newBan(tc.typ, tc.value)
+		assert.Equal(t, tc.err, err, tc.value)
+	}
+	ban, _ := newBan(model.BanIP, "10.0.0.0/8")
+	assert.True(t, ban.CIDR())
+	assert.True(t, ban.Match("10.1.2.3"))
+	assert.False(t, ban.Match("192.168.0.1"))
+	assert.False(t, ban.Expired(1))
+	ban.Expire = 100
+	assert.True(t, ban.Expired(100))
+}
@@ -1,6 +1,7 @@
 package conf
 
 import (
+	"errors"
 	"flag"
 	"os"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/bilibili/discovery/naming"
 	xtime "github.com/Terry-Mao/goim/pkg/time"
+	"github.com/Terry-Mao/goim/pkg/trace"
 
 	"github.com/BurntSushi/toml"
 )
@@ -40,7 +42,12 @@ func init() {
 // Init init config.
 func Init() (err error) {
 	Conf = Default()
-	_, err = toml.DecodeFile(confPath, &Conf)
+	if _, err = toml.DecodeFile(confPath, &Conf); err != nil {
+		return
+	}
+	if Conf.Webhook.Open && Conf.Webhook.FailOpen && !Conf.Auth.Open {
+		err = errors.New("webhook.failOpen requires auth open")
+	}
 	return
 }
 
@@ -67,6 +74,10 @@ func Default() *Config {
 			KeepAliveTimeout:  xtime.Duration(time.Second * 20),
 		},
 		Backoff: &Backoff{MaxDelay: 300, BaseDelay: 3, Factor: 1.8, Jitter: 1.3},
+		Auth:    &Auth{Algs: []string{"HS256"}, Leeway: xtime.Duration(time.Second * 30), RequireExp: true},
+		Authz:   &Authz{},
+		Inbox:   &Inbox{Size: 100, TTL: xtime.Duration(time.Hour * 24)},
+		Webhook: &Webhook{Timeout: xtime.Duration(time.Millisecond * 500), CacheTTL: xtime.Duration(time.Second * 10), CacheSize: 10240},
 	}
 }
 
@@ -82,6 +93,12 @@ type Config struct {
 	Node       *Node
 	Backoff    *Backoff
 	Regions    map[string][]string
+	Backends   []*Backend
+	Auth       *Auth
+	Webhook    *Webhook
+	Authz      *Authz
+	Inbox      *Inbox
+	Trace      *trace.Config
 }
 
 // Env is env config.
@@ -129,8 +146,60 @@ type Redis struct {
 
 // Kafka .
 type Kafka struct {
-	Topic   string
-	Brokers []string
+	Topic        string
+	ReceiveTopic string // upstream client messages, empty disabled
+	Brokers      []string
+}
+
+// Backend is a business service receiving the upstream messages of the
+// operations in [MinOp, MaxOp].
+type Backend struct {
+	Name    string
+	Target  string // grpc target, e.g. 127.0.0.1:9000, or discovery://default/appid resolved by the discovery registered in main
+	MinOp   int32
+	MaxOp   int32
+	Timeout xtime.Duration // 1s if not set
+	Reply   bool           // push the reply body back to the sender key
+}
+
+// Auth is signed token (JWT) authentication config, the plain json token is
+// trusted if not open.
+type Auth struct {
+	Open       bool
+	Issuer     string   // required iss, empty not checked
+	Audience   string   // required aud, empty not checked
+	Algs       []string // allowed algorithms: HS256/384/512, RS256/384/512, PS256/384/512, ES256/384/512
+	KeyFiles   []string // hmac secret, pem public key or jwks json files, reloaded on SIGHUP
+	Leeway     xtime.Duration
+	MaxAge     xtime.Duration // max age since iat, zero not checked
+	RequireExp bool
+}
+
+// Webhook is the http authentication delegate config.
+type Webhook struct {
+	Open      bool
+	URL       string
+	Timeout   xtime.Duration
+	CacheTTL  xtime.Duration // result cache, zero disabled
+	CacheSize int
+	FailOpen  bool // verify the signed token if the webhook is unavailable, auth must be open
+}
+
+// Authz is the room, op and topic authorization config, all allowed if not
+// open. The other rooms need the mid in the room acl, the other ops and
+// topics need the token claims.
+type Authz struct {
+	Open         bool
+	PublicRooms  []string // room prefixes anyone may enter, e.g. live://
+	PublicOps    []int32  // ops anyone may subscribe
+	PublicTopics []string // topic prefixes anyone may subscribe, e.g. stock:
+}
+
+// Inbox is the offline message inbox config of the mids.
+type Inbox struct {
+	Open bool
+	Size int            // max messages per mid
+	TTL  xtime.Duration // expire the inbox since the last message
 }
 
 // RPCClient is RPC client config.
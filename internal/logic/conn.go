@@ -2,7 +2,6 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
 	"time"
 
 	"github.com/Terry-Mao/goim/api/protocol"
@@ -12,29 +11,52 @@ import (
 )
 
 // Connect connected a conn.
-func (l *Logic) Connect(c context.Context, server, cookie string, token []byte) (mid int64, key, roomID string, accepts []int32, hb int64, err error) {
-	var params struct {
-		Mid      int64   `json:"mid"`
-		Key      string  `json:"key"`
-		RoomID   string  `json:"room_id"`
-		Platform string  `json:"platform"`
-		Accepts  []int32 `json:"accepts"`
-	}
-	if err = json.Unmarshal(token, &params); err != nil {
-		log.Errorf("json.Unmarshal(%s) error(%v)", token, err)
+func (l *Logic) Connect(c context.Context, server, ip, cookie string, token []byte) (mid int64, key, roomID string, accepts []int32, hb, expire int64, rooms []string, ops []int32, topics []string, err error) {
+	var params authParams
+	if err = l.auth(c, server, ip, cookie, token, &params); err != nil {
 		return
 	}
 	mid = params.Mid
 	roomID = params.RoomID
+	expire = int64(params.Expire)
+	rooms = params.Rooms
+	ops = params.Ops
+	topics = params.Topics
 	accepts = params.Accepts
 	hb = int64(l.c.Node.Heartbeat) * int64(l.c.Node.HeartbeatMax)
+	if params.Heartbeat > 0 {
+		hb = int64(time.Duration(params.Heartbeat)*time.Second) * int64(l.c.Node.HeartbeatMax)
+	}
 	if key = params.Key; key == "" {
 		key = uuid.New().String()
 	}
+	if ban, e := l.dao.Banned(c, mid, key, ip); e != nil {
+		log.Errorf("l.dao.Banned(%d,%s,%s) error(%v)", mid, key, ip, e)
+	} else if ban != nil {
+		log.Warningf("conn banned key:%s ip:%s mid:%d ban:%s reason:%s", key, ip, mid, ban.Field(), ban.Reason)
+		err = ErrBanned
+		return
+	}
 	if err = l.dao.AddMapping(c, mid, key, server); err != nil {
 		log.Errorf("l.dao.AddMapping(%d,%s,%s) error(%v)", mid, key, server, err)
 	}
-	log.Infof("conn connected key:%s server:%s mid:%d token:%s", key, server, mid, token)
+	log.Infof("conn connected key:%s server:%s ip:%s mid:%d token:%s", key, server, ip, mid, token)
+	return
+}
+
+// Reauth re-authenticate a conn by a new token to extend the session, the
+// token must be of the same mid.
+func (l *Logic) Reauth(c context.Context, mid int64, key, server, ip, cookie string, token []byte) (expire int64, err error) {
+	var params authParams
+	if err = l.auth(c, server, ip, cookie, token, &params); err != nil {
+		return
+	}
+	if params.Mid != mid {
+		log.Errorf("reauth key:%s mid:%d token mid:%d mismatch", key, mid, params.Mid)
+		return 0, ErrUnauthorized
+	}
+	expire = int64(params.Expire)
+	log.Infof("conn reauth key:%s server:%s mid:%d expire:%d", key, server, mid, expire)
 	return
 }
 
@@ -48,6 +70,16 @@ func (l *Logic) Disconnect(c context.Context, mid int64, key, server string) (ha
 	return
 }
 
+// Disconnects disconnect a batch of conns, conns is key to mid.
+func (l *Logic) Disconnects(c context.Context, server string, conns map[string]int64) (err error) {
+	if err = l.dao.DelMappings(c, server, conns); err != nil {
+		log.Errorf("l.dao.DelMappings(%s,%d) error(%v)", server, len(conns), err)
+		return
+	}
+	log.Infof("conns disconnected server:%s count:%d", server, len(conns))
+	return
+}
+
 // Heartbeat heartbeat a conn.
 func (l *Logic) Heartbeat(c context.Context, mid int64, key, server string) (err error) {
 	has, err := l.dao.ExpireMapping(c, mid, key)
@@ -78,8 +110,17 @@ func (l *Logic) RenewOnline(c context.Context, server string, roomCount map[stri
 	return l.roomCount, nil
 }
 
-// Receive receive a message.
-func (l *Logic) Receive(c context.Context, mid int64, proto *protocol.Proto) (err error) {
-	log.Infof("receive mid:%d message:%+v", mid, proto)
+// Receive receive a message, code and msg are the ack status for client.
+func (l *Logic) Receive(c context.Context, mid int64, key, server, room string, proto *protocol.Proto) (code int32, msg string, err error) {
+	if l.c.Kafka.ReceiveTopic != "" {
+		if err = l.dao.ReceiveMsg(c, mid, key, server, room, proto); err != nil {
+			log.Errorf("l.dao.ReceiveMsg(%d,%s,%s) error(%v)", mid, key, server, err)
+			return
+		}
+	}
+	if b := l.backend(proto.Op); b != nil {
+		code, msg, err = l.receiveBackend(c, b, mid, key, server, room, proto)
+	}
+	log.Infof("receive mid:%d key:%s server:%s message:%+v", mid, key, server, proto)
 	return
 }
@@ -12,19 +12,30 @@ func TestConnect(t *testing.T) {
 	var (
 		server    = "test_server"
 		serverKey = "test_server_key"
+		ip        = "127.0.0.1"
 		cookie    = ""
 		token     = []byte(`{"mid":1, "key":"test_server_key", "room_id":"test://test_room", "platform":"web", "accepts":[1000,1001,1002]}`)
 		ol        = map[string]int32{"test://test_room": 100}
 		c         = context.Background()
 	)
 	// connect
-	mid, key, roomID, accepts, hb, err := lg.Connect(c, server, cookie, token)
+	mid, key, roomID, accepts, hb, expire, rooms, ops, topics, err := lg.Connect(c, server, ip, cookie, token)
 	assert.Nil(t, err)
 	assert.Equal(t, serverKey, key)
 	assert.Equal(t, roomID, "test://test_room")
 	assert.Equal(t, len(accepts), 3)
 	assert.NotZero(t, hb)
+	assert.Zero(t, expire)
+	assert.Empty(t, rooms)
+	assert.Empty(t, ops)
+	assert.Empty(t, topics)
 	t.Log(mid, key, roomID, accepts, err)
+	// reauth
+	expire, err = lg.Reauth(c, mid, key, server, ip, cookie, token)
+	assert.Nil(t, err)
+	assert.Zero(t, expire)
+	_, err = lg.Reauth(c, mid+1, key, server, ip, cookie, token)
+	assert.Equal(t, ErrUnauthorized, err)
 	// heartbeat
 	err = lg.Heartbeat(c, mid, key, server)
 	assert.Nil(t, err)
@@ -32,11 +43,15 @@ func TestConnect(t *testing.T) {
 	has, err := lg.Disconnect(c, mid, key, server)
 	assert.Nil(t, err)
 	assert.Equal(t, true, has)
+	// disconnects
+	err = lg.Disconnects(c, server, map[string]int64{key: mid})
+	assert.Nil(t, err)
 	// renew
 	online, err := lg.RenewOnline(c, server, ol)
 	assert.Nil(t, err)
 	assert.NotNil(t, online)
 	// message
-	err = lg.Receive(c, mid, &protocol.Proto{})
+	code, _, err := lg.Receive(c, mid, key, server, "test://test_room", &protocol.Proto{})
 	assert.Nil(t, err)
+	assert.Equal(t, int32(0), code)
 }
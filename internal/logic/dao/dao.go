@@ -55,7 +55,7 @@ func newRedis(c *conf.Redis) *redis.Pool {
 			if err != nil {
 				return nil, err
 			}
-			return conn, nil
+			return &meterConn{Conn: conn}, nil
 		},
 	}
 }
@@ -3,8 +3,10 @@ package dao
 import (
 	"context"
 	"strconv"
+	"time"
 
 	pb "github.com/Terry-Mao/goim/api/logic"
+	"github.com/Terry-Mao/goim/api/protocol"
 	log "github.com/golang/glog"
 	"github.com/golang/protobuf/proto"
 	sarama "gopkg.in/Shopify/sarama.v1"
@@ -12,13 +14,20 @@ import (
 
 // PushMsg push a message to databus.
 func (d *Dao) PushMsg(c context.Context, op int32, server string, keys []string, msg []byte) (err error) {
+	return d.PushSeqMsg(c, op, server, keys, nil, msg)
+}
+
+// PushSeqMsg push a message with the inbox seq of each key to databus.
+func (d *Dao) PushSeqMsg(c context.Context, op int32, server string, keys []string, seqs []int32, msg []byte) (err error) {
 	pushMsg := &pb.PushMsg{
 		Type:      pb.PushMsg_PUSH,
 		Operation: op,
 		Server:    server,
 		Keys:      keys,
 		Msg:       msg,
+		Seqs:      seqs,
 	}
+	span := startProduce(c, pushMsg)
 	b, err := proto.Marshal(pushMsg)
 	if err != nil {
 		return
@@ -28,7 +37,7 @@ func (d *Dao) PushMsg(c context.Context, op int32, server string, keys []string,
 		Topic: d.c.Kafka.Topic,
 		Value: sarama.ByteEncoder(b),
 	}
-	if _, _, err = d.kafkaPub.SendMessage(m); err != nil {
+	if err = d.send("push", m, span); err != nil {
 		log.Errorf("PushMsg.send(push pushMsg:%v) error(%v)", pushMsg, err)
 	}
 	return
@@ -42,6 +51,7 @@ func (d *Dao) BroadcastRoomMsg(c context.Context, op int32, room string, msg []b
 		Room:      room,
 		Msg:       msg,
 	}
+	span := startProduce(c, pushMsg)
 	b, err := proto.Marshal(pushMsg)
 	if err != nil {
 		return
@@ -51,12 +61,36 @@ func (d *Dao) BroadcastRoomMsg(c context.Context, op int32, room string, msg []b
 		Topic: d.c.Kafka.Topic,
 		Value: sarama.ByteEncoder(b),
 	}
-	if _, _, err = d.kafkaPub.SendMessage(m); err != nil {
+	if err = d.send("room", m, span); err != nil {
 		log.Errorf("PushMsg.send(broadcast_room pushMsg:%v) error(%v)", pushMsg, err)
 	}
 	return
 }
 
+// BroadcastTopicMsg push a topic message to databus.
+func (d *Dao) BroadcastTopicMsg(c context.Context, op int32, topic string, msg []byte) (err error) {
+	pushMsg := &pb.PushMsg{
+		Type:      pb.PushMsg_TOPIC,
+		Operation: op,
+		Topic:     topic,
+		Msg:       msg,
+	}
+	span := startProduce(c, pushMsg)
+	b, err := proto.Marshal(pushMsg)
+	if err != nil {
+		return
+	}
+	m := &sarama.ProducerMessage{
+		Key:   sarama.StringEncoder(topic),
+		Topic: d.c.Kafka.Topic,
+		Value: sarama.ByteEncoder(b),
+	}
+	if err = d.send("topic", m, span); err != nil {
+		log.Errorf("PushMsg.send(broadcast_topic pushMsg:%v) error(%v)", pushMsg, err)
+	}
+	return
+}
+
 // BroadcastMsg push a message to databus.
 func (d *Dao) BroadcastMsg(c context.Context, op, speed int32, msg []byte) (err error) {
 	pushMsg := &pb.PushMsg{
@@ -65,6 +99,7 @@ func (d *Dao) BroadcastMsg(c context.Context, op, speed int32, msg []byte) (err
 		Speed:     speed,
 		Msg:       msg,
 	}
+	span := startProduce(c, pushMsg)
 	b, err := proto.Marshal(pushMsg)
 	if err != nil {
 		return
@@ -74,8 +109,68 @@ func (d *Dao) BroadcastMsg(c context.Context, op, speed int32, msg []byte) (err
 		Topic: d.c.Kafka.Topic,
 		Value: sarama.ByteEncoder(b),
 	}
-	if _, _, err = d.kafkaPub.SendMessage(m); err != nil {
+	if err = d.send("broadcast", m, span); err != nil {
 		log.Errorf("PushMsg.send(broadcast pushMsg:%v) error(%v)", pushMsg, err)
 	}
 	return
 }
+
+// ReceiveMsg publish a client message to databus, keyed by mid to keep the
+// order of a user.
+func (d *Dao) ReceiveMsg(c context.Context, mid int64, key, server, room string, p *protocol.Proto) (err error) {
+	receiveMsg := &pb.ReceiveMsg{
+		Mid:       mid,
+		Key:       key,
+		Server:    server,
+		Room:      room,
+		Timestamp: time.Now().Unix(),
+		Proto:     p,
+	}
+	b, err := proto.Marshal(receiveMsg)
+	if err != nil {
+		return
+	}
+	pk := key
+	if mid > 0 {
+		pk = strconv.FormatInt(mid, 10)
+	}
+	m := &sarama.ProducerMessage{
+		Key:   sarama.StringEncoder(pk),
+		Topic: d.c.Kafka.ReceiveTopic,
+		Value: sarama.ByteEncoder(b),
+	}
+	if err = d.send("receive", m, nil); err != nil {
+		log.Errorf("ReceiveMsg.send(receiveMsg:%v) error(%v)", receiveMsg, err)
+	}
+	return
+}
+
+// KickMsg publish a kick of the keys of the server, or all in the room or of
+// the ip to databus.
+func (d *Dao) KickMsg(c context.Context, server string, keys []string, room, ip, reason string) (err error) {
+	pushMsg := &pb.PushMsg{
+		Type:   pb.PushMsg_KICK,
+		Server: server,
+		Keys:   keys,
+		Room:   room,
+		Ip:     ip,
+		Msg:    []byte(reason),
+	}
+	b, err := proto.Marshal(pushMsg)
+	if err != nil {
+		return
+	}
+	pk := room + ip
+	if len(keys) > 0 {
+		pk = keys[0]
+	}
+	m := &sarama.ProducerMessage{
+		Key:   sarama.StringEncoder(pk),
+		Topic: d.c.Kafka.Topic,
+		Value: sarama.ByteEncoder(b),
+	}
+	if err = d.send("kick", m, nil); err != nil {
+		log.Errorf("PushMsg.send(kick pushMsg:%v) error(%v)", pushMsg, err)
+	}
+	return
+}
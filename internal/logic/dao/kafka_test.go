@@ -4,9 +4,39 @@ import (
 	"context"
 	"testing"
 
+	pb "github.com/Terry-Mao/goim/api/logic"
+	"github.com/Terry-Mao/goim/api/protocol"
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
+	sarama "gopkg.in/Shopify/sarama.v1"
 )
 
+// fakePub keep the produced messages.
+type fakePub struct {
+	msgs []*sarama.ProducerMessage
+}
+
+func (p *fakePub) SendMessage(m *sarama.ProducerMessage) (int32, int64, error) {
+	p.msgs = append(p.msgs, m)
+	return 0, int64(len(p.msgs)), nil
+}
+
+func (p *fakePub) SendMessages(ms []*sarama.ProducerMessage) error {
+	p.msgs = append(p.msgs, ms...)
+	return nil
+}
+
+func (p *fakePub) Close() error { return nil }
+
+// pushMsg decode the produced push message.
+func (p *fakePub) pushMsg(t *testing.T, i int) (m *pb.PushMsg) {
+	m = new(pb.PushMsg)
+	b, err := p.msgs[i].Value.Encode()
+	assert.Nil(t, err)
+	assert.Nil(t, proto.Unmarshal(b, m))
+	return
+}
+
 func TestDaoPushMsg(t *testing.T) {
 	var (
 		c      = context.Background()
@@ -19,6 +49,25 @@ func TestDaoPushMsg(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestDaoPushSeqMsg(t *testing.T) {
+	var (
+		c    = context.Background()
+		pub  = new(fakePub)
+		d    = &Dao{c: d.c, kafkaPub: pub}
+		keys = []string{"key1", "key2"}
+		seqs = []int32{10, 20}
+	)
+	err := d.PushSeqMsg(c, 100, "test", keys, seqs, []byte("msg"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(pub.msgs))
+	m := pub.pushMsg(t, 0)
+	assert.Equal(t, pb.PushMsg_PUSH, m.Type)
+	assert.Equal(t, int32(100), m.Operation)
+	assert.Equal(t, keys, m.Keys)
+	assert.Equal(t, seqs, m.Seqs)
+	assert.Equal(t, "msg", string(m.Msg))
+}
+
 func TestDaoBroadcastRoomMsg(t *testing.T) {
 	var (
 		c    = context.Background()
@@ -30,6 +79,17 @@ func TestDaoBroadcastRoomMsg(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestDaoBroadcastTopicMsg(t *testing.T) {
+	var (
+		c     = context.Background()
+		op    = int32(100)
+		topic = "stock:AAPL"
+		msg   = []byte("msg")
+	)
+	err := d.BroadcastTopicMsg(c, op, topic, msg)
+	assert.Nil(t, err)
+}
+
 func TestDaoBroadcastMsg(t *testing.T) {
 	var (
 		c     = context.Background()
@@ -40,3 +100,51 @@ func TestDaoBroadcastMsg(t *testing.T) {
 	err := d.BroadcastMsg(c, op, speed, msg)
 	assert.Nil(t, err)
 }
+
+func TestDaoReceiveMsg(t *testing.T) {
+	var (
+		c      = context.Background()
+		mid    = int64(1)
+		key    = "key"
+		server = "test"
+		room   = "test://1"
+		p      = &protocol.Proto{Ver: 1, Op: 1000, Seq: 1, Body: []byte("msg")}
+	)
+	err := d.ReceiveMsg(c, mid, key, server, room, p)
+	assert.Nil(t, err)
+}
+
+func TestDaoKickMsg(t *testing.T) {
+	var (
+		c      = context.Background()
+		pub    = new(fakePub)
+		d      = &Dao{c: d.c, kafkaPub: pub}
+		server = "test"
+		keys   = []string{"key1", "key2"}
+	)
+	err := d.KickMsg(c, server, keys, "", "", "kicked")
+	assert.Nil(t, err)
+	err = d.KickMsg(c, "", nil, "test://1", "", "room kicked")
+	assert.Nil(t, err)
+	err = d.KickMsg(c, "", nil, "", "127.0.0.1", "ip kicked")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(pub.msgs))
+	for i, m := range pub.msgs {
+		assert.Equal(t, d.c.Kafka.Topic, m.Topic, i)
+	}
+	m := pub.pushMsg(t, 0)
+	assert.Equal(t, pb.PushMsg_KICK, m.Type)
+	assert.Equal(t, server, m.Server)
+	assert.Equal(t, keys, m.Keys)
+	assert.Equal(t, "kicked", string(m.Msg))
+	pk, _ := pub.msgs[0].Key.Encode()
+	assert.Equal(t, "key1", string(pk))
+	m = pub.pushMsg(t, 1)
+	assert.Equal(t, pb.PushMsg_KICK, m.Type)
+	assert.Equal(t, "test://1", m.Room)
+	assert.Empty(t, m.Keys)
+	assert.Equal(t, "room kicked", string(m.Msg))
+	m = pub.pushMsg(t, 2)
+	assert.Equal(t, "127.0.0.1", m.Ip)
+	assert.Equal(t, "ip kicked", string(m.Msg))
+}
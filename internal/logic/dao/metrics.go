@@ -0,0 +1,109 @@
+package dao
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	pb "github.com/Terry-Mao/goim/api/logic"
+	"github.com/Terry-Mao/goim/pkg/trace"
+	"github.com/gomodule/redigo/redis"
+	"github.com/prometheus/client_golang/prometheus"
+	sarama "gopkg.in/Shopify/sarama.v1"
+)
+
+var (
+	redisDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "goim",
+		Subsystem: "logic",
+		Name:      "redis_duration_seconds",
+		Help:      "Latency of the redis commands and pipelines (by the first command).",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5},
+	}, []string{"command", "pipeline"})
+	kafkaDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "goim",
+		Subsystem: "logic",
+		Name:      "kafka_produce_duration_seconds",
+		Help:      "Latency of the kafka produces per message type.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"type"})
+	kafkaErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "goim",
+		Subsystem: "logic",
+		Name:      "kafka_produce_errors_total",
+		Help:      "Failed kafka produces per message type.",
+	}, []string{"type"})
+)
+
+func init() {
+	prometheus.MustRegister(redisDuration, kafkaDuration, kafkaErrors)
+}
+
+// send produce the message and observe the latency by the message type,
+// the span of the push is finished if traced.
+func (d *Dao) send(typ string, m *sarama.ProducerMessage, span *trace.Span) (err error) {
+	start := time.Now()
+	_, _, err = d.kafkaPub.SendMessage(m)
+	kafkaDuration.WithLabelValues(typ).Observe(time.Since(start).Seconds())
+	if err != nil {
+		kafkaErrors.WithLabelValues(typ).Inc()
+	}
+	span.Finish(err)
+	return
+}
+
+// startProduce start the producer span of the push if traced, the
+// traceparent is carried by the push.
+func startProduce(c context.Context, pushMsg *pb.PushMsg) (span *trace.Span) {
+	if span = trace.Start("kafka.produce", trace.KindProducer, trace.FromContext(c).Traceparent()); span != nil {
+		span.SetAttr("type", pushMsg.Type)
+		span.SetAttr("op", pushMsg.Operation)
+		pushMsg.Trace = span.Traceparent()
+	}
+	return
+}
+
+// meterConn observe the latency of the redis commands, a pipeline is
+// observed from the first Send to the last Receive.
+type meterConn struct {
+	redis.Conn
+	cmd     string // the first command of the pipeline
+	pending int
+	start   time.Time
+}
+
+func (c *meterConn) Send(cmd string, args ...interface{}) error {
+	if c.pending == 0 {
+		c.cmd = strings.ToUpper(cmd)
+		c.start = time.Now()
+	}
+	c.pending++
+	return c.Conn.Send(cmd, args...)
+}
+
+func (c *meterConn) Receive() (reply interface{}, err error) {
+	reply, err = c.Conn.Receive()
+	if c.pending > 0 {
+		if c.pending--; c.pending == 0 {
+			redisDuration.WithLabelValues(c.cmd, "true").Observe(time.Since(c.start).Seconds())
+		}
+	}
+	return
+}
+
+func (c *meterConn) Do(cmd string, args ...interface{}) (reply interface{}, err error) {
+	if c.pending > 0 {
+		// Do flushes and receives all the pending replies
+		reply, err = c.Conn.Do(cmd, args...)
+		redisDuration.WithLabelValues(c.cmd, "true").Observe(time.Since(c.start).Seconds())
+		c.pending = 0
+		return
+	}
+	if cmd == "" {
+		return c.Conn.Do(cmd, args...)
+	}
+	start := time.Now()
+	reply, err = c.Conn.Do(cmd, args...)
+	redisDuration.WithLabelValues(strings.ToUpper(cmd), "false").Observe(time.Since(start).Seconds())
+	return
+}
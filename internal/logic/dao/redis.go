@@ -1,11 +1,15 @@
 package dao
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Terry-Mao/goim/api/protocol"
 	"github.com/Terry-Mao/goim/internal/logic/model"
 	log "github.com/golang/glog"
 	"github.com/gomodule/redigo/redis"
@@ -14,9 +18,16 @@ import (
 )
 
 const (
-	_prefixMidServer    = "mid_%d" // mid -> key:server
-	_prefixKeyServer    = "key_%s" // key -> server
-	_prefixServerOnline = "ol_%s"  // server -> online
+	_prefixMidServer    = "mid_%d"       // mid -> key:server
+	_prefixKeyServer    = "key_%s"       // key -> server
+	_prefixServerOnline = "ol_%s"        // server -> online
+	_prefixRoomACL      = "acl_%s"       // room -> allowed mids
+	_prefixInbox        = "inbox_%d"     // mid -> inbox messages scored by seq
+	_prefixInboxSeq     = "inbox_seq_%d" // mid -> last inbox seq
+	_prefixBan          = "ban_%s"       // type:value -> ban, expires with the ban
+
+	_keyBans     = "ban_fields"      // type:value scored by the expire
+	_keyBanCIDRs = "ban_cidr_fields" // ip:cidr scored by the expire
 )
 
 func keyMidServer(mid int64) string {
@@ -31,6 +42,22 @@ func keyServerOnline(key string) string {
 	return fmt.Sprintf(_prefixServerOnline, key)
 }
 
+func keyRoomACL(room string) string {
+	return fmt.Sprintf(_prefixRoomACL, room)
+}
+
+func keyInbox(mid int64) string {
+	return fmt.Sprintf(_prefixInbox, mid)
+}
+
+func keyInboxSeq(mid int64) string {
+	return fmt.Sprintf(_prefixInboxSeq, mid)
+}
+
+func keyBan(field string) string {
+	return fmt.Sprintf(_prefixBan, field)
+}
+
 // pingRedis check redis connection.
 func (d *Dao) pingRedis(c context.Context) (err error) {
 	conn := d.redis.Get()
@@ -137,6 +164,41 @@ func (d *Dao) DelMapping(c context.Context, mid int64, key, server string) (has
 	return
 }
 
+// delMappingScript del the key mapping and the mid mapping of the key only if
+// they still point to the server, a reconnect on another server is kept.
+var delMappingScript = redis.NewScript(2, `
+if redis.call('GET', KEYS[1]) == ARGV[2] then
+	redis.call('DEL', KEYS[1])
+end
+if redis.call('HGET', KEYS[2], ARGV[1]) == ARGV[2] then
+	redis.call('HDEL', KEYS[2], ARGV[1])
+end
+return 0
+`)
+
+// DelMappings del a batch of mappings of the server, conns is key to mid.
+func (d *Dao) DelMappings(c context.Context, server string, conns map[string]int64) (err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	for key, mid := range conns {
+		if err = delMappingScript.Send(conn, keyKeyServer(key), keyMidServer(mid), key, server); err != nil {
+			log.Errorf("delMappingScript.Send(%d,%s,%s) error(%v)", mid, key, server, err)
+			return
+		}
+	}
+	if err = conn.Flush(); err != nil {
+		log.Errorf("conn.Flush() error(%v)", err)
+		return
+	}
+	for i := 0; i < len(conns); i++ {
+		if _, err = conn.Receive(); err != nil {
+			log.Errorf("conn.Receive() error(%v)", err)
+			return
+		}
+	}
+	return
+}
+
 // ServersByKeys get a server by key.
 func (d *Dao) ServersByKeys(c context.Context, keys []string) (res []string, err error) {
 	conn := d.redis.Get()
@@ -184,6 +246,34 @@ func (d *Dao) KeysByMids(c context.Context, mids []int64) (ress map[string]strin
 	return
 }
 
+// MidKeys get the key server of each mid.
+func (d *Dao) MidKeys(c context.Context, mids []int64) (res map[int64]map[string]string, err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	for _, mid := range mids {
+		if err = conn.Send("HGETALL", keyMidServer(mid)); err != nil {
+			log.Errorf("conn.Send(HGETALL %d) error(%v)", mid, err)
+			return
+		}
+	}
+	if err = conn.Flush(); err != nil {
+		log.Errorf("conn.Flush() error(%v)", err)
+		return
+	}
+	res = make(map[int64]map[string]string, len(mids))
+	for _, mid := range mids {
+		var keys map[string]string
+		if keys, err = redis.StringMap(conn.Receive()); err != nil {
+			log.Errorf("conn.Receive() error(%v)", err)
+			return
+		}
+		if len(keys) > 0 {
+			res[mid] = keys
+		}
+	}
+	return
+}
+
 // AddServerOnline add a server online.
 func (d *Dao) AddServerOnline(c context.Context, server string, online *model.Online) (err error) {
 	roomsMap := map[uint32]map[string]int32{}
@@ -277,3 +367,279 @@ func (d *Dao) DelServerOnline(c context.Context, server string) (err error) {
 	}
 	return
 }
+
+// AddRoomACL allow the mids to enter the room.
+func (d *Dao) AddRoomACL(c context.Context, room string, mids []int64) (err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	args := redis.Args{}.Add(keyRoomACL(room)).AddFlat(mids)
+	if _, err = conn.Do("SADD", args...); err != nil {
+		log.Errorf("conn.Do(SADD %s,%v) error(%v)", room, mids, err)
+	}
+	return
+}
+
+// DelRoomACL disallow the mids to enter the room.
+func (d *Dao) DelRoomACL(c context.Context, room string, mids []int64) (err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	args := redis.Args{}.Add(keyRoomACL(room)).AddFlat(mids)
+	if _, err = conn.Do("SREM", args...); err != nil {
+		log.Errorf("conn.Do(SREM %s,%v) error(%v)", room, mids, err)
+	}
+	return
+}
+
+// ExistsRoomACL check if the mid allowed to enter the room.
+func (d *Dao) ExistsRoomACL(c context.Context, room string, mid int64) (has bool, err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	if has, err = redis.Bool(conn.Do("SISMEMBER", keyRoomACL(room), mid)); err != nil {
+		log.Errorf("conn.Do(SISMEMBER %s,%d) error(%v)", room, mid, err)
+	}
+	return
+}
+
+func keyBans(ban *model.Ban) string {
+	if ban.CIDR() {
+		return _keyBanCIDRs
+	}
+	return _keyBans
+}
+
+// AddBan add a ban, the ban key expires with the ban and the field is indexed
+// by the expire, +inf if never expires.
+func (d *Dao) AddBan(c context.Context, ban *model.Ban) (err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	b, err := json.Marshal(ban)
+	if err != nil {
+		return
+	}
+	var (
+		n     = 2
+		score = "+inf"
+	)
+	if err = conn.Send("SET", keyBan(ban.Field()), b); err != nil {
+		log.Errorf("conn.Send(SET %s) error(%v)", ban.Field(), err)
+		return
+	}
+	if ban.Expire > 0 {
+		if err = conn.Send("EXPIREAT", keyBan(ban.Field()), ban.Expire); err != nil {
+			log.Errorf("conn.Send(EXPIREAT %s,%d) error(%v)", ban.Field(), ban.Expire, err)
+			return
+		}
+		score = strconv.FormatInt(ban.Expire, 10)
+		n++
+	}
+	if err = conn.Send("ZADD", keyBans(ban), score, ban.Field()); err != nil {
+		log.Errorf("conn.Send(ZADD %s,%s) error(%v)", ban.Field(), score, err)
+		return
+	}
+	if err = conn.Flush(); err != nil {
+		log.Errorf("conn.Flush() error(%v)", err)
+		return
+	}
+	for i := 0; i < n; i++ {
+		if _, err = conn.Receive(); err != nil {
+			log.Errorf("conn.Receive() error(%v)", err)
+			return
+		}
+	}
+	return
+}
+
+// DelBan delete a ban.
+func (d *Dao) DelBan(c context.Context, ban *model.Ban) (err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	if err = conn.Send("DEL", keyBan(ban.Field())); err != nil {
+		log.Errorf("conn.Send(DEL %s) error(%v)", ban.Field(), err)
+		return
+	}
+	if err = conn.Send("ZREM", keyBans(ban), ban.Field()); err != nil {
+		log.Errorf("conn.Send(ZREM %s) error(%v)", ban.Field(), err)
+		return
+	}
+	if err = conn.Flush(); err != nil {
+		log.Errorf("conn.Flush() error(%v)", err)
+		return
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = conn.Receive(); err != nil {
+			log.Errorf("conn.Receive() error(%v)", err)
+			return
+		}
+	}
+	return
+}
+
+// Bans get all the unexpired bans, the expired fields are removed from the
+// index.
+func (d *Dao) Bans(c context.Context) (bans []*model.Ban, err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	now := time.Now().Unix()
+	for _, key := range []string{_keyBans, _keyBanCIDRs} {
+		if _, err = conn.Do("ZREMRANGEBYSCORE", key, "-inf", now); err != nil {
+			log.Errorf("conn.Do(ZREMRANGEBYSCORE %s,%d) error(%v)", key, now, err)
+			return
+		}
+		var fields []string
+		if fields, err = redis.Strings(conn.Do("ZRANGE", key, 0, -1)); err != nil {
+			log.Errorf("conn.Do(ZRANGE %s) error(%v)", key, err)
+			return
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		args := redis.Args{}
+		for _, field := range fields {
+			args = args.Add(keyBan(field))
+		}
+		var values [][]byte
+		if values, err = redis.ByteSlices(conn.Do("MGET", args...)); err != nil {
+			log.Errorf("conn.Do(MGET %v) error(%v)", args, err)
+			return
+		}
+		for _, b := range values {
+			if b == nil {
+				continue
+			}
+			ban := new(model.Ban)
+			if err = json.Unmarshal(b, ban); err != nil {
+				log.Errorf("json.Unmarshal(%s) error(%v)", b, err)
+				return
+			}
+			bans = append(bans, ban)
+		}
+	}
+	return
+}
+
+// Banned get the unexpired ban of the mid, key or ip, nil if not banned.
+func (d *Dao) Banned(c context.Context, mid int64, key, ip string) (ban *model.Ban, err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	keys := redis.Args{}.Add(keyBan(model.BanField(model.BanKey, key)), keyBan(model.BanField(model.BanIP, ip)))
+	if mid > 0 {
+		keys = keys.Add(keyBan(model.BanField(model.BanMid, strconv.FormatInt(mid, 10))))
+	}
+	now := time.Now().Unix()
+	if err = conn.Send("MGET", keys...); err != nil {
+		log.Errorf("conn.Send(MGET %v) error(%v)", keys, err)
+		return
+	}
+	if err = conn.Send("ZRANGEBYSCORE", _keyBanCIDRs, fmt.Sprintf("(%d", now), "+inf"); err != nil {
+		log.Errorf("conn.Send(ZRANGEBYSCORE %s) error(%v)", _keyBanCIDRs, err)
+		return
+	}
+	if err = conn.Flush(); err != nil {
+		log.Errorf("conn.Flush() error(%v)", err)
+		return
+	}
+	var (
+		values [][]byte
+		cidrs  []string
+	)
+	if values, err = redis.ByteSlices(conn.Receive()); err != nil {
+		log.Errorf("conn.Receive() error(%v)", err)
+		return
+	}
+	if cidrs, err = redis.Strings(conn.Receive()); err != nil {
+		log.Errorf("conn.Receive() error(%v)", err)
+		return
+	}
+	for _, field := range cidrs {
+		cidr := &model.Ban{Type: model.BanIP, Value: strings.TrimPrefix(field, model.BanField(model.BanIP, ""))}
+		if cidr.Match(ip) {
+			var b []byte
+			if b, err = redis.Bytes(conn.Do("GET", keyBan(field))); err != nil {
+				if err == redis.ErrNil {
+					err = nil
+					continue
+				}
+				log.Errorf("conn.Do(GET %s) error(%v)", field, err)
+				return
+			}
+			values = append(values, b)
+		}
+	}
+	for _, b := range values {
+		if b == nil {
+			continue
+		}
+		ban = new(model.Ban)
+		if err = json.Unmarshal(b, ban); err != nil {
+			log.Errorf("json.Unmarshal(%s) error(%v)", b, err)
+			return nil, err
+		}
+		if !ban.Expired(now) {
+			return
+		}
+	}
+	return nil, nil
+}
+
+// inboxScript incr the seq of the mid, add the message scored by the seq,
+// trim the inbox to the size and expire it. The seq never expires so it
+// never goes back.
+var inboxScript = redis.NewScript(2, `
+local seq = redis.call('INCR', KEYS[2])
+redis.call('ZADD', KEYS[1], seq, seq .. ':' .. ARGV[1] .. ':' .. ARGV[2])
+redis.call('ZREMRANGEBYRANK', KEYS[1], 0, -(tonumber(ARGV[3]) + 1))
+redis.call('EXPIRE', KEYS[1], ARGV[4])
+return seq
+`)
+
+// AddInbox add a message to the inbox of the mids, returns the seq of each.
+func (d *Dao) AddInbox(c context.Context, mids []int64, op int32, msg []byte) (seqs map[int64]int64, err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	var (
+		size = d.c.Inbox.Size
+		ttl  = int64(time.Duration(d.c.Inbox.TTL) / time.Second)
+	)
+	for _, mid := range mids {
+		if err = inboxScript.Send(conn, keyInbox(mid), keyInboxSeq(mid), op, msg, size, ttl); err != nil {
+			log.Errorf("inboxScript.Send(%d) error(%v)", mid, err)
+			return
+		}
+	}
+	if err = conn.Flush(); err != nil {
+		log.Errorf("conn.Flush() error(%v)", err)
+		return
+	}
+	seqs = make(map[int64]int64, len(mids))
+	for _, mid := range mids {
+		if seqs[mid], err = redis.Int64(conn.Receive()); err != nil {
+			log.Errorf("conn.Receive() error(%v)", err)
+			return
+		}
+	}
+	return
+}
+
+// Inbox get the inbox messages of the mid after the seq.
+func (d *Dao) Inbox(c context.Context, mid, seq int64) (protos []*protocol.Proto, err error) {
+	conn := d.redis.Get()
+	defer conn.Close()
+	values, err := redis.ByteSlices(conn.Do("ZRANGEBYSCORE", keyInbox(mid), fmt.Sprintf("(%d", seq), "+inf"))
+	if err != nil {
+		log.Errorf("conn.Do(ZRANGEBYSCORE %d,%d) error(%v)", mid, seq, err)
+		return
+	}
+	for _, b := range values {
+		// seq:op:body
+		parts := bytes.SplitN(b, []byte(":"), 3)
+		if len(parts) != 3 {
+			log.Errorf("invalid inbox message(%s) mid:%d", b, mid)
+			continue
+		}
+		s, _ := strconv.ParseInt(string(parts[0]), 10, 64)
+		op, _ := strconv.ParseInt(string(parts[1]), 10, 32)
+		// the proto seq is the low 32 bits of the inbox seq
+		protos = append(protos, &protocol.Proto{Ver: 1, Op: int32(op), Seq: int32(s), Body: parts[2]})
+	}
+	return
+}
@@ -3,8 +3,10 @@ package dao
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Terry-Mao/goim/internal/logic/model"
+	"github.com/gomodule/redigo/redis"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -41,12 +43,30 @@ func TestDaoAddMapping(t *testing.T) {
 	assert.Equal(t, server, ress[key])
 	assert.Equal(t, mid, mids[0])
 
+	midKeys, err := d.MidKeys(c, []int64{mid, 2})
+	assert.Nil(t, err)
+	assert.Equal(t, map[int64]map[string]string{mid: {key: server}}, midKeys)
+
 	has, err = d.DelMapping(c, 0, "test", server)
 	assert.Nil(t, err)
 	assert.NotEqual(t, false, has)
 	has, err = d.DelMapping(c, mid, key, server)
 	assert.Nil(t, err)
 	assert.NotEqual(t, false, has)
+
+	err = d.AddMapping(c, mid, key, server)
+	assert.Nil(t, err)
+	// a reconnect on another server is kept
+	err = d.DelMappings(c, "other_server", map[string]int64{key: mid})
+	assert.Nil(t, err)
+	has, err = d.ExpireMapping(c, mid, key)
+	assert.Nil(t, err)
+	assert.Equal(t, true, has)
+	err = d.DelMappings(c, server, map[string]int64{key: mid, "test": 0})
+	assert.Nil(t, err)
+	has, err = d.ExpireMapping(c, mid, key)
+	assert.Nil(t, err)
+	assert.Equal(t, false, has)
 }
 
 func TestDaoAddServerOnline(t *testing.T) {
@@ -67,3 +87,93 @@ func TestDaoAddServerOnline(t *testing.T) {
 	err = d.DelServerOnline(c, server)
 	assert.Nil(t, err)
 }
+
+func TestDaoRoomACL(t *testing.T) {
+	var (
+		c    = context.Background()
+		room = "test://private"
+	)
+	err := d.AddRoomACL(c, room, []int64{1, 2})
+	assert.Nil(t, err)
+	has, err := d.ExistsRoomACL(c, room, 1)
+	assert.Nil(t, err)
+	assert.True(t, has)
+	err = d.DelRoomACL(c, room, []int64{1})
+	assert.Nil(t, err)
+	has, err = d.ExistsRoomACL(c, room, 1)
+	assert.Nil(t, err)
+	assert.False(t, has)
+}
+
+func TestDaoBan(t *testing.T) {
+	var (
+		c    = context.Background()
+		ban  = &model.Ban{Type: model.BanMid, Value: "100", Reason: "spam"}
+		cidr = &model.Ban{Type: model.BanIP, Value: "10.0.0.0/8", Reason: "spam"}
+	)
+	err := d.AddBan(c, ban)
+	assert.Nil(t, err)
+	err = d.AddBan(c, cidr)
+	assert.Nil(t, err)
+	res, err := d.Banned(c, 100, "test_key", "127.0.0.1")
+	assert.Nil(t, err)
+	assert.Equal(t, ban.Field(), res.Field())
+	res, err = d.Banned(c, 101, "test_key", "10.1.2.3")
+	assert.Nil(t, err)
+	assert.Equal(t, cidr.Field(), res.Field())
+	assert.Equal(t, "spam", res.Reason)
+	bans, err := d.Bans(c)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, bans)
+	assert.Nil(t, d.DelBan(c, ban))
+	assert.Nil(t, d.DelBan(c, cidr))
+	res, err = d.Banned(c, 100, "test_key", "10.1.2.3")
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	// the ban key expires with the ban
+	ban.Expire = time.Now().Add(time.Minute).Unix()
+	assert.Nil(t, d.AddBan(c, ban))
+	conn := d.redis.Get()
+	ttl, err := redis.Int64(conn.Do("TTL", keyBan(ban.Field())))
+	conn.Close()
+	assert.Nil(t, err)
+	assert.True(t, ttl > 0 && ttl <= 60)
+	assert.Nil(t, d.DelBan(c, ban))
+	// the expired field is removed from the index
+	ban.Expire = time.Now().Unix() - 1
+	assert.Nil(t, d.AddBan(c, ban))
+	res, err = d.Banned(c, 100, "test_key", "127.0.0.1")
+	assert.Nil(t, err)
+	assert.Nil(t, res)
+	bans, err = d.Bans(c)
+	assert.Nil(t, err)
+	for _, b := range bans {
+		assert.NotEqual(t, ban.Field(), b.Field())
+	}
+	conn = d.redis.Get()
+	n, err := redis.Int(conn.Do("ZCARD", _keyBans))
+	conn.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, n)
+}
+
+func TestDaoInbox(t *testing.T) {
+	var (
+		c   = context.Background()
+		mid = int64(1)
+		msg = []byte("a:b")
+	)
+	seqs, err := d.AddInbox(c, []int64{mid}, 1000, msg)
+	assert.Nil(t, err)
+	seq := seqs[mid]
+	assert.NotZero(t, seq)
+	seqs, err = d.AddInbox(c, []int64{mid}, 1001, msg)
+	assert.Nil(t, err)
+	assert.Equal(t, seq+1, seqs[mid])
+	protos, err := d.Inbox(c, mid, seq)
+	assert.Nil(t, err)
+	assert.Len(t, protos, 1)
+	assert.Equal(t, int32(1001), protos[0].Op)
+	assert.Equal(t, int32(seq+1), protos[0].Seq)
+	assert.Equal(t, msg, protos[0].Body)
+}
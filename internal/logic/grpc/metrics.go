@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+	"path"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "goim",
+	Subsystem: "logic",
+	Name:      "rpc_duration_seconds",
+	Help:      "Latency of the received rpc requests (Connect, Disconnect, Heartbeat etc.) per method and code.",
+	Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+}, []string{"method", "code"})
+
+func init() {
+	prometheus.MustRegister(rpcDuration)
+}
+
+// metrics count and observe the latency of the rpc requests.
+func metrics(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	start := time.Now()
+	resp, err = handler(ctx, req)
+	rpcDuration.WithLabelValues(path.Base(info.FullMethod), status.Code(err).String()).Observe(time.Since(start).Seconds())
+	return
+}
@@ -10,7 +10,9 @@ import (
 	"github.com/Terry-Mao/goim/internal/logic/conf"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
 
 	// use gzip decoder
 	_ "google.golang.org/grpc/encoding/gzip"
@@ -25,7 +27,7 @@ func New(c *conf.RPCServer, l *logic.Logic) *grpc.Server {
 		Timeout:               time.Duration(c.KeepAliveTimeout),
 		MaxConnectionAge:      time.Duration(c.MaxLifeTime),
 	})
-	srv := grpc.NewServer(keepParams)
+	srv := grpc.NewServer(keepParams, grpc.UnaryInterceptor(metrics))
 	pb.RegisterLogicServer(srv, &server{l})
 	lis, err := net.Listen(c.Network, c.Addr)
 	if err != nil {
@@ -47,11 +49,47 @@ var _ pb.LogicServer = &server{}
 
 // Connect connect a conn.
 func (s *server) Connect(ctx context.Context, req *pb.ConnectReq) (*pb.ConnectReply, error) {
-	mid, key, room, accepts, hb, err := s.srv.Connect(ctx, req.Server, req.Cookie, req.Token)
+	mid, key, room, accepts, hb, expire, rooms, ops, topics, err := s.srv.Connect(ctx, req.Server, req.ClientIP, req.Cookie, req.Token)
 	if err != nil {
+		if err == logic.ErrUnauthorized {
+			err = status.Error(codes.Unauthenticated, err.Error())
+		} else if err == logic.ErrBanned {
+			err = status.Error(codes.PermissionDenied, err.Error())
+		}
 		return &pb.ConnectReply{}, err
 	}
-	return &pb.ConnectReply{Mid: mid, Key: key, RoomID: room, Accepts: accepts, Heartbeat: hb}, nil
+	return &pb.ConnectReply{Mid: mid, Key: key, RoomID: room, Accepts: accepts, Heartbeat: hb, Expire: expire, Rooms: rooms, Ops: ops, Topics: topics}, nil
+}
+
+// Sync get the inbox messages after the seq.
+func (s *server) Sync(ctx context.Context, req *pb.SyncReq) (*pb.SyncReply, error) {
+	protos, err := s.srv.Sync(ctx, req.Mid, req.Key, req.Seq)
+	if err != nil {
+		return &pb.SyncReply{}, err
+	}
+	return &pb.SyncReply{Protos: protos}, nil
+}
+
+// Authorize check if a conn may enter the room or subscribe the ops and the
+// topics.
+func (s *server) Authorize(ctx context.Context, req *pb.AuthorizeReq) (*pb.AuthorizeReply, error) {
+	allow, err := s.srv.Authorize(ctx, req.Mid, req.Key, req.RoomID, req.Ops, req.Topics)
+	if err != nil {
+		return &pb.AuthorizeReply{}, err
+	}
+	return &pb.AuthorizeReply{Allow: allow}, nil
+}
+
+// Reauth re-authenticate a conn.
+func (s *server) Reauth(ctx context.Context, req *pb.ReauthReq) (*pb.ReauthReply, error) {
+	expire, err := s.srv.Reauth(ctx, req.Mid, req.Key, req.Server, req.ClientIP, req.Cookie, req.Token)
+	if err != nil {
+		if err == logic.ErrUnauthorized {
+			err = status.Error(codes.Unauthenticated, err.Error())
+		}
+		return &pb.ReauthReply{}, err
+	}
+	return &pb.ReauthReply{Expire: expire}, nil
 }
 
 // Disconnect disconnect a conn.
@@ -63,6 +101,14 @@ func (s *server) Disconnect(ctx context.Context, req *pb.DisconnectReq) (*pb.Dis
 	return &pb.DisconnectReply{Has: has}, nil
 }
 
+// Disconnects disconnect a batch of conns.
+func (s *server) Disconnects(ctx context.Context, req *pb.DisconnectsReq) (*pb.DisconnectsReply, error) {
+	if err := s.srv.Disconnects(ctx, req.Server, req.Conns); err != nil {
+		return &pb.DisconnectsReply{}, err
+	}
+	return &pb.DisconnectsReply{}, nil
+}
+
 // Heartbeat beartbeat a conn.
 func (s *server) Heartbeat(ctx context.Context, req *pb.HeartbeatReq) (*pb.HeartbeatReply, error) {
 	if err := s.srv.Heartbeat(ctx, req.Mid, req.Key, req.Server); err != nil {
@@ -82,10 +128,11 @@ func (s *server) RenewOnline(ctx context.Context, req *pb.OnlineReq) (*pb.Online
 
 // Receive receive a message.
 func (s *server) Receive(ctx context.Context, req *pb.ReceiveReq) (*pb.ReceiveReply, error) {
-	if err := s.srv.Receive(ctx, req.Mid, req.Proto); err != nil {
+	code, msg, err := s.srv.Receive(ctx, req.Mid, req.Key, req.Server, req.Room, req.Proto)
+	if err != nil {
 		return &pb.ReceiveReply{}, err
 	}
-	return &pb.ReceiveReply{}, nil
+	return &pb.ReceiveReply{Code: code, Msg: msg}, nil
 }
 
 // nodes return nodes.
@@ -0,0 +1,41 @@
+package http
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) addRoomACL(c *gin.Context) {
+	var arg struct {
+		Type string  `form:"type" binding:"required"`
+		Room string  `form:"room" binding:"required"`
+		Mids []int64 `form:"mids" binding:"required"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.AddRoomACL(context.TODO(), arg.Type, arg.Room, arg.Mids); err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) delRoomACL(c *gin.Context) {
+	var arg struct {
+		Type string  `form:"type" binding:"required"`
+		Room string  `form:"room" binding:"required"`
+		Mids []int64 `form:"mids" binding:"required"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.DelRoomACL(context.TODO(), arg.Type, arg.Room, arg.Mids); err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
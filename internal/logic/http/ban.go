@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/logic"
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) addBan(c *gin.Context) {
+	var arg struct {
+		Type   string `form:"type" binding:"required"`
+		Value  string `form:"value" binding:"required"`
+		Reason string `form:"reason"`
+		TTL    int64  `form:"ttl"` // seconds, zero never expires
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.AddBan(context.TODO(), arg.Type, arg.Value, arg.Reason, time.Duration(arg.TTL)*time.Second); err != nil {
+		if err == logic.ErrBanArg {
+			errors(c, RequestErr, err.Error())
+			return
+		}
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) delBan(c *gin.Context) {
+	var arg struct {
+		Type  string `form:"type" binding:"required"`
+		Value string `form:"value" binding:"required"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.DelBan(context.TODO(), arg.Type, arg.Value); err != nil {
+		if err == logic.ErrBanArg {
+			errors(c, RequestErr, err.Error())
+			return
+		}
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) bans(c *gin.Context) {
+	res, err := s.logic.Bans(context.TODO())
+	if err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, res, OK)
+}
@@ -0,0 +1,72 @@
+package http
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) kickKeys(c *gin.Context) {
+	var arg struct {
+		Keys   []string `form:"keys" binding:"required"`
+		Reason string   `form:"reason"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.KickKeys(context.TODO(), arg.Keys, arg.Reason); err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) kickMids(c *gin.Context) {
+	var arg struct {
+		Mids   []int64 `form:"mids" binding:"required"`
+		Reason string  `form:"reason"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.KickMids(context.TODO(), arg.Mids, arg.Reason); err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) kickRoom(c *gin.Context) {
+	var arg struct {
+		Type   string `form:"type" binding:"required"`
+		Room   string `form:"room" binding:"required"`
+		Reason string `form:"reason"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.KickRoom(context.TODO(), arg.Type, arg.Room, arg.Reason); err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) kickIP(c *gin.Context) {
+	var arg struct {
+		IP     string `form:"ip" binding:"required"`
+		Reason string `form:"reason"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	if err := s.logic.KickIP(context.TODO(), arg.IP, arg.Reason); err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
@@ -4,9 +4,21 @@ import (
 	"context"
 	"io/ioutil"
 
+	"github.com/Terry-Mao/goim/pkg/trace"
 	"github.com/gin-gonic/gin"
 )
 
+// startTrace start the span of a push continued from the traceparent
+// header, the traceparent of the span is set to the response header.
+func startTrace(c *gin.Context, name string, op int32) (context.Context, *trace.Span) {
+	span := trace.StartRoot(name, trace.KindServer, c.GetHeader("traceparent"))
+	if span != nil {
+		span.SetAttr("op", op)
+		c.Header("traceparent", span.Traceparent())
+	}
+	return trace.NewContext(c, span), span
+}
+
 func (s *Server) pushKeys(c *gin.Context) {
 	var arg struct {
 		Op   int32    `form:"operation"`
@@ -22,7 +34,10 @@ func (s *Server) pushKeys(c *gin.Context) {
 		errors(c, RequestErr, err.Error())
 		return
 	}
-	if err = s.logic.PushKeys(context.TODO(), arg.Op, arg.Keys, msg); err != nil {
+	ctx, span := startTrace(c, "push.keys", arg.Op)
+	err = s.logic.PushKeys(ctx, arg.Op, arg.Keys, msg)
+	span.Finish(err)
+	if err != nil {
 		result(c, nil, RequestErr)
 		return
 	}
@@ -44,7 +59,10 @@ func (s *Server) pushMids(c *gin.Context) {
 		errors(c, RequestErr, err.Error())
 		return
 	}
-	if err = s.logic.PushMids(context.TODO(), arg.Op, arg.Mids, msg); err != nil {
+	ctx, span := startTrace(c, "push.mids", arg.Op)
+	err = s.logic.PushMids(ctx, arg.Op, arg.Mids, msg)
+	span.Finish(err)
+	if err != nil {
 		errors(c, ServerErr, err.Error())
 		return
 	}
@@ -67,7 +85,35 @@ func (s *Server) pushRoom(c *gin.Context) {
 		errors(c, RequestErr, err.Error())
 		return
 	}
-	if err = s.logic.PushRoom(c, arg.Op, arg.Type, arg.Room, msg); err != nil {
+	ctx, span := startTrace(c, "push.room", arg.Op)
+	err = s.logic.PushRoom(ctx, arg.Op, arg.Type, arg.Room, msg)
+	span.Finish(err)
+	if err != nil {
+		errors(c, ServerErr, err.Error())
+		return
+	}
+	result(c, nil, OK)
+}
+
+func (s *Server) pushTopic(c *gin.Context) {
+	var arg struct {
+		Op    int32  `form:"operation" binding:"required"`
+		Topic string `form:"topic" binding:"required"`
+	}
+	if err := c.BindQuery(&arg); err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	// read message
+	msg, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		errors(c, RequestErr, err.Error())
+		return
+	}
+	ctx, span := startTrace(c, "push.topic", arg.Op)
+	err = s.logic.PushTopic(ctx, arg.Op, arg.Topic, msg)
+	span.Finish(err)
+	if err != nil {
 		errors(c, ServerErr, err.Error())
 		return
 	}
@@ -88,7 +134,10 @@ func (s *Server) pushAll(c *gin.Context) {
 		errors(c, RequestErr, err.Error())
 		return
 	}
-	if err = s.logic.PushAll(c, arg.Op, arg.Speed, msg); err != nil {
+	ctx, span := startTrace(c, "push.all", arg.Op)
+	err = s.logic.PushAll(ctx, arg.Op, arg.Speed, msg)
+	span.Finish(err)
+	if err != nil {
 		errors(c, ServerErr, err.Error())
 		return
 	}
@@ -5,6 +5,7 @@ import (
 	"github.com/Terry-Mao/goim/internal/logic/conf"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 // Server is http server.
@@ -35,12 +36,23 @@ func (s *Server) initRouter() {
 	group.POST("/push/keys", s.pushKeys)
 	group.POST("/push/mids", s.pushMids)
 	group.POST("/push/room", s.pushRoom)
+	group.POST("/push/topic", s.pushTopic)
 	group.POST("/push/all", s.pushAll)
+	group.POST("/kick/keys", s.kickKeys)
+	group.POST("/kick/mids", s.kickMids)
+	group.POST("/kick/room", s.kickRoom)
+	group.POST("/kick/ip", s.kickIP)
+	group.POST("/ban/add", s.addBan)
+	group.POST("/ban/del", s.delBan)
+	group.GET("/ban/list", s.bans)
 	group.GET("/online/top", s.onlineTop)
 	group.GET("/online/room", s.onlineRoom)
 	group.GET("/online/total", s.onlineTotal)
 	group.GET("/nodes/weighted", s.nodesWeighted)
 	group.GET("/nodes/instances", s.nodesInstances)
+	group.POST("/acl/room", s.addRoomACL)
+	group.POST("/acl/room/del", s.delRoomACL)
+	s.engine.GET("/metrics", gin.WrapH(promhttp.Handler()))
 }
 
 // Close close the server.
@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"context"
+
+	"github.com/Terry-Mao/goim/api/protocol"
+	log "github.com/golang/glog"
+)
+
+func (l *Logic) inboxOpen() bool {
+	return l.c.Inbox != nil && l.c.Inbox.Open
+}
+
+// pushInbox save the message to the inbox of the mids, then push it with the
+// inbox seq of each mid to the online keys, a message per server. The proto
+// seq is int32 so the inbox seq wraps around after 2^31 messages of a mid.
+func (l *Logic) pushInbox(c context.Context, op int32, mids []int64, msg []byte) (err error) {
+	seqs, err := l.dao.AddInbox(c, mids, op, msg)
+	if err != nil {
+		return
+	}
+	midKeys, err := l.dao.MidKeys(c, mids)
+	if err != nil {
+		return
+	}
+	var (
+		keys    = make(map[string][]string)
+		keySeqs = make(map[string][]int32)
+	)
+	for mid, keyServers := range midKeys {
+		for key, server := range keyServers {
+			if key == "" || server == "" {
+				log.Warningf("push key:%s server:%s is empty", key, server)
+				continue
+			}
+			keys[server] = append(keys[server], key)
+			keySeqs[server] = append(keySeqs[server], int32(seqs[mid]))
+		}
+	}
+	for server, keys := range keys {
+		if err = l.dao.PushSeqMsg(c, op, server, keys, keySeqs[server], msg); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Sync get the inbox messages of the mid after the last seen seq.
+func (l *Logic) Sync(c context.Context, mid int64, key string, seq int64) (protos []*protocol.Proto, err error) {
+	if !l.inboxOpen() || mid == 0 {
+		return
+	}
+	if protos, err = l.dao.Inbox(c, mid, seq); err != nil {
+		return
+	}
+	log.Infof("conn sync key:%s mid:%d seq:%d count:%d", key, mid, seq, len(protos))
+	return
+}
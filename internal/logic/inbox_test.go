@@ -0,0 +1,16 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncClosed(t *testing.T) {
+	l := &Logic{c: &conf.Config{Inbox: &conf.Inbox{}}}
+	protos, err := l.Sync(context.Background(), 1, "key", 0)
+	assert.Nil(t, err)
+	assert.Empty(t, protos)
+}
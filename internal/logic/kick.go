@@ -0,0 +1,67 @@
+package logic
+
+import (
+	"context"
+	"sort"
+
+	"github.com/Terry-Mao/goim/internal/logic/model"
+	log "github.com/golang/glog"
+)
+
+// KickKeys disconnect the keys.
+func (l *Logic) KickKeys(c context.Context, keys []string, reason string) (err error) {
+	servers, err := l.dao.ServersByKeys(c, keys)
+	if err != nil {
+		return
+	}
+	keyServers := make(map[string]string, len(keys))
+	for i, key := range keys {
+		keyServers[key] = servers[i]
+	}
+	for server, keys := range kickKeys(keyServers) {
+		if err = l.dao.KickMsg(c, server, keys, "", "", reason); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// KickMids disconnect all keys of the mids.
+func (l *Logic) KickMids(c context.Context, mids []int64, reason string) (err error) {
+	keyServers, _, err := l.dao.KeysByMids(c, mids)
+	if err != nil {
+		return
+	}
+	for server, keys := range kickKeys(keyServers) {
+		if err = l.dao.KickMsg(c, server, keys, "", "", reason); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// kickKeys group the keys by the server, the offline keys are skipped.
+func kickKeys(keyServers map[string]string) (res map[string][]string) {
+	res = make(map[string][]string)
+	for key, server := range keyServers {
+		if key == "" || server == "" {
+			log.Warningf("kick key:%s server:%s is empty", key, server)
+			continue
+		}
+		res[server] = append(res[server], key)
+	}
+	for _, keys := range res {
+		sort.Strings(keys)
+	}
+	return
+}
+
+// KickRoom disconnect all in the room.
+func (l *Logic) KickRoom(c context.Context, typ, room, reason string) (err error) {
+	return l.dao.KickMsg(c, "", nil, model.EncodeRoomKey(typ, room), "", reason)
+}
+
+// KickIP disconnect all of the ip.
+func (l *Logic) KickIP(c context.Context, ip, reason string) (err error) {
+	return l.dao.KickMsg(c, "", nil, "", ip, reason)
+}
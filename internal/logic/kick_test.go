@@ -0,0 +1,37 @@
+package logic
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKick(t *testing.T) {
+	var (
+		c      = context.TODO()
+		reason = "kicked"
+	)
+	err := lg.KickKeys(c, []string{"test_key"}, reason)
+	assert.Nil(t, err)
+	err = lg.KickMids(c, []int64{1, 2, 3}, reason)
+	assert.Nil(t, err)
+	err = lg.KickRoom(c, "test", "test_room", reason)
+	assert.Nil(t, err)
+	err = lg.KickIP(c, "127.0.0.1", reason)
+	assert.Nil(t, err)
+}
+
+func TestKickKeys(t *testing.T) {
+	res := kickKeys(map[string]string{
+		"key1": "server1",
+		"key2": "server2",
+		"key3": "server1",
+		"key4": "",
+		"":     "server1",
+	})
+	assert.Equal(t, map[string][]string{
+		"server1": {"key1", "key3"},
+		"server2": {"key2"},
+	}, res)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/Terry-Mao/goim/internal/logic/conf"
 	"github.com/Terry-Mao/goim/internal/logic/dao"
 	"github.com/Terry-Mao/goim/internal/logic/model"
+	"github.com/Terry-Mao/goim/pkg/jwt"
 	"github.com/bilibili/discovery/naming"
 	log "github.com/golang/glog"
 )
@@ -30,6 +31,11 @@ type Logic struct {
 	nodes        []*naming.Instance
 	loadBalancer *LoadBalancer
 	regions      map[string]string // province -> region
+	// upstream
+	backends []*backend
+	// auth
+	verifier *jwt.Verifier // nil if signed token disabled
+	webhook  *webhook      // nil if auth webhook disabled
 }
 
 // New init
@@ -40,6 +46,9 @@ func New(c *conf.Config) (l *Logic) {
 		dis:          naming.New(c.Discovery),
 		loadBalancer: NewLoadBalancer(),
 		regions:      make(map[string]string),
+		backends:     newBackends(c.Backends),
+		verifier:     newVerifier(c.Auth),
+		webhook:      newWebhook(c.Webhook),
 	}
 	l.initRegions()
 	l.initNodes()
@@ -0,0 +1,54 @@
+package model
+
+import (
+	"net"
+	"strings"
+)
+
+const (
+	// BanMid ban a mid.
+	BanMid = "mid"
+	// BanKey ban a key.
+	BanKey = "key"
+	// BanIP ban an ip or a cidr.
+	BanIP = "ip"
+)
+
+// Ban is a ban of a mid, key or ip/cidr.
+type Ban struct {
+	Type    string `json:"type"`
+	Value   string `json:"value"`
+	Reason  string `json:"reason"`
+	Created int64  `json:"created"`
+	Expire  int64  `json:"expire"` // unix seconds, zero never expires
+}
+
+// Field get the field of the ban.
+func (b *Ban) Field() string {
+	return BanField(b.Type, b.Value)
+}
+
+// Expired check if the ban expired at now.
+func (b *Ban) Expired(now int64) bool {
+	return b.Expire > 0 && b.Expire <= now
+}
+
+// CIDR check if the ban is an ip cidr.
+func (b *Ban) CIDR() bool {
+	return b.Type == BanIP && strings.Contains(b.Value, "/")
+}
+
+// Match check if the cidr ban contains the ip.
+func (b *Ban) Match(ip string) bool {
+	_, ipnet, err := net.ParseCIDR(b.Value)
+	if err != nil {
+		return false
+	}
+	addr := net.ParseIP(ip)
+	return addr != nil && ipnet.Contains(addr)
+}
+
+// BanField get the field of a ban.
+func BanField(typ, value string) string {
+	return typ + ":" + value
+}
@@ -29,8 +29,12 @@ func (l *Logic) PushKeys(c context.Context, op int32, keys []string, msg []byte)
 	return
 }
 
-// PushMids push a message by mid.
+// PushMids push a message by mid, the message is saved to the inbox of the
+// mids if open, so the offline mids sync it on reconnect.
 func (l *Logic) PushMids(c context.Context, op int32, mids []int64, msg []byte) (err error) {
+	if l.inboxOpen() {
+		return l.pushInbox(c, op, mids, msg)
+	}
 	keyServers, _, err := l.dao.KeysByMids(c, mids)
 	if err != nil {
 		return
@@ -56,6 +60,11 @@ func (l *Logic) PushRoom(c context.Context, op int32, typ, room string, msg []by
 	return l.dao.BroadcastRoomMsg(c, op, model.EncodeRoomKey(typ, room), msg)
 }
 
+// PushTopic push a message to the subscribers of the topic.
+func (l *Logic) PushTopic(c context.Context, op int32, topic string, msg []byte) (err error) {
+	return l.dao.BroadcastTopicMsg(c, op, topic, msg)
+}
+
 // PushAll push a message to all.
 func (l *Logic) PushAll(c context.Context, op, speed int32, msg []byte) (err error) {
 	return l.dao.BroadcastMsg(c, op, speed, msg)
@@ -41,6 +41,17 @@ func TestPushRoom(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestPushTopic(t *testing.T) {
+	var (
+		c     = context.TODO()
+		op    = int32(100)
+		topic = "match:123:score"
+		msg   = []byte("hello")
+	)
+	err := lg.PushTopic(c, op, topic, msg)
+	assert.Nil(t, err)
+}
+
 func TestPushAll(t *testing.T) {
 	var (
 		c     = context.TODO()
@@ -0,0 +1,122 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	log "github.com/golang/glog"
+)
+
+// errWebhookUnavailable the webhook is not available.
+var errWebhookUnavailable = errors.New("auth webhook unavailable")
+
+// webhook delegates the authentication to an http service, the request is
+// a json of token, cookie, ip and server, the service replies 200 with the
+// authParams json, or 401/403 if unauthorized.
+type webhook struct {
+	c      *conf.Webhook
+	client *http.Client
+
+	mutex sync.Mutex
+	cache map[string]*webhookResult
+}
+
+type webhookResult struct {
+	params  authParams
+	err     error
+	expires time.Time
+}
+
+type webhookReq struct {
+	Token  string `json:"token"`
+	Cookie string `json:"cookie"`
+	IP     string `json:"ip"`
+	Server string `json:"server"`
+}
+
+func newWebhook(c *conf.Webhook) *webhook {
+	if c == nil || !c.Open {
+		return nil
+	}
+	return &webhook{
+		c:      c,
+		client: &http.Client{Timeout: time.Duration(c.Timeout)},
+		cache:  make(map[string]*webhookResult),
+	}
+}
+
+// auth returns nil, ErrUnauthorized or errWebhookUnavailable.
+func (w *webhook) auth(c context.Context, server, ip, cookie string, token []byte, params *authParams) (err error) {
+	ck := ip + "\n" + cookie + "\n" + string(token)
+	if r := w.get(ck); r != nil {
+		*params = r.params
+		return r.err
+	}
+	b, _ := json.Marshal(&webhookReq{Token: string(token), Cookie: cookie, IP: ip, Server: server})
+	req, err := http.NewRequest(http.MethodPost, w.c.URL, bytes.NewReader(b))
+	if err != nil {
+		log.Errorf("http.NewRequest(%s) error(%v)", w.c.URL, err)
+		return errWebhookUnavailable
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := w.client.Do(req.WithContext(c))
+	if err != nil {
+		log.Errorf("webhook.Do(%s) error(%v)", w.c.URL, err)
+		return errWebhookUnavailable
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err = json.NewDecoder(resp.Body).Decode(params); err != nil {
+			log.Errorf("webhook(%s) decode error(%v)", w.c.URL, err)
+			return errWebhookUnavailable
+		}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		err = ErrUnauthorized
+	default:
+		log.Errorf("webhook(%s) status code(%d)", w.c.URL, resp.StatusCode)
+		return errWebhookUnavailable
+	}
+	w.set(ck, &webhookResult{params: *params, err: err})
+	return
+}
+
+func (w *webhook) get(key string) (r *webhookResult) {
+	if w.c.CacheTTL <= 0 {
+		return
+	}
+	w.mutex.Lock()
+	if r = w.cache[key]; r != nil && time.Now().After(r.expires) {
+		delete(w.cache, key)
+		r = nil
+	}
+	w.mutex.Unlock()
+	return
+}
+
+func (w *webhook) set(key string, r *webhookResult) {
+	if w.c.CacheTTL <= 0 {
+		return
+	}
+	now := time.Now()
+	r.expires = now.Add(time.Duration(w.c.CacheTTL))
+	w.mutex.Lock()
+	if len(w.cache) >= w.c.CacheSize {
+		for k, v := range w.cache {
+			if now.After(v.expires) {
+				delete(w.cache, k)
+			}
+		}
+	}
+	// keep the live entries, skip caching until some expire
+	if len(w.cache) < w.c.CacheSize {
+		w.cache[key] = r
+	}
+	w.mutex.Unlock()
+}
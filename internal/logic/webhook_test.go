@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Terry-Mao/goim/internal/logic/conf"
+	xtime "github.com/Terry-Mao/goim/pkg/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhook(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req webhookReq
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		calls++
+		if req.Token != "valid" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"mid":123,"key":"key","room_id":"live://1000","accepts":[1000],"heartbeat":60}`))
+	}))
+	defer srv.Close()
+	w := newWebhook(&conf.Webhook{
+		Open:      true,
+		URL:       srv.URL,
+		Timeout:   xtime.Duration(time.Second),
+		CacheTTL:  xtime.Duration(time.Minute),
+		CacheSize: 10,
+	})
+	var (
+		c      = context.Background()
+		params authParams
+	)
+	for i := 0; i < 2; i++ {
+		err := w.auth(c, "server", "127.0.0.1", "", []byte("valid"), &params)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(123), params.Mid)
+		assert.Equal(t, "live://1000", params.RoomID)
+		assert.Equal(t, int64(60), params.Heartbeat)
+	}
+	assert.Equal(t, 1, calls)
+	// cached by ip
+	err := w.auth(c, "server", "127.0.0.2", "", []byte("valid"), &params)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, calls)
+	err = w.auth(c, "server", "127.0.0.1", "", []byte("invalid"), &params)
+	assert.Equal(t, ErrUnauthorized, err)
+	srv.Close()
+	err = w.auth(c, "server", "127.0.0.1", "", []byte("other"), &params)
+	assert.Equal(t, errWebhookUnavailable, err)
+}
+
+func TestWebhookCacheFull(t *testing.T) {
+	w := newWebhook(&conf.Webhook{
+		Open:      true,
+		CacheTTL:  xtime.Duration(time.Minute),
+		CacheSize: 2,
+	})
+	w.set("a", &webhookResult{})
+	w.set("b", &webhookResult{})
+	w.set("c", &webhookResult{})
+	assert.NotNil(t, w.get("a"))
+	assert.NotNil(t, w.get("b"))
+	assert.Nil(t, w.get("c"))
+	w.cache["a"].expires = time.Now().Add(-time.Second)
+	w.set("c", &webhookResult{})
+	assert.Nil(t, w.get("a"))
+	assert.NotNil(t, w.get("c"))
+}
+
+func TestAuthFailOpen(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c := &conf.Webhook{
+		Open:     true,
+		URL:      srv.URL,
+		Timeout:  xtime.Duration(time.Second),
+		FailOpen: true,
+	}
+	l := &Logic{c: &conf.Config{Webhook: c}, webhook: newWebhook(c)}
+	var params authParams
+	err := l.auth(context.Background(), "server", "127.0.0.1", "", []byte(`{"mid":123}`), &params)
+	assert.Equal(t, errWebhookUnavailable, err)
+	assert.Equal(t, int64(0), params.Mid)
+}
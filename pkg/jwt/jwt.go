@@ -0,0 +1,253 @@
+// Package jwt verifies the signed JSON Web Tokens, see RFC 7519, it supports
+// the HMAC (HS*), RSA (RS*, PS*) and ECDSA (ES*) algorithms.
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	// register the hash functions
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMalformed malformed token
+	ErrMalformed = errors.New("jwt: malformed token")
+	// ErrAlgorithm algorithm not allowed
+	ErrAlgorithm = errors.New("jwt: algorithm not allowed")
+	// ErrKeyNotFound no key for the token
+	ErrKeyNotFound = errors.New("jwt: key not found")
+	// ErrSignature invalid signature
+	ErrSignature = errors.New("jwt: invalid signature")
+	// ErrExpired token expired
+	ErrExpired = errors.New("jwt: token expired")
+	// ErrNotValidYet token not valid yet
+	ErrNotValidYet = errors.New("jwt: token not valid yet")
+	// ErrIssuer invalid issuer
+	ErrIssuer = errors.New("jwt: invalid issuer")
+	// ErrAudience invalid audience
+	ErrAudience = errors.New("jwt: invalid audience")
+)
+
+var hashes = map[string]crypto.Hash{
+	"HS256": crypto.SHA256,
+	"HS384": crypto.SHA384,
+	"HS512": crypto.SHA512,
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// Config is the verifier config.
+type Config struct {
+	Issuer     string        // required issuer, empty not checked
+	Audience   string        // required audience, empty not checked
+	Algs       []string      // allowed algorithms, e.g. HS256, RS256, ES256
+	Leeway     time.Duration // clock skew of exp, nbf and iat
+	MaxAge     time.Duration // max age since iat, zero not checked
+	RequireExp bool          // reject the tokens without exp
+}
+
+// Verifier verifies the tokens by the keys loaded from files, the keys can
+// be rotated by Reload.
+type Verifier struct {
+	c     *Config
+	algs  map[string]bool
+	files []string
+
+	mutex sync.RWMutex
+	keys  []*Key
+}
+
+// NewVerifier new a verifier and load the key files.
+func NewVerifier(c *Config, files []string) (v *Verifier, err error) {
+	v = &Verifier{c: c, algs: make(map[string]bool), files: files}
+	for _, alg := range c.Algs {
+		if _, ok := hashes[alg]; !ok {
+			return nil, ErrAlgorithm
+		}
+		v.algs[alg] = true
+	}
+	if err = v.Reload(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Reload reload the keys from files.
+func (v *Verifier) Reload() (err error) {
+	keys, err := LoadKeys(v.files...)
+	if err != nil {
+		return
+	}
+	v.mutex.Lock()
+	v.keys = keys
+	v.mutex.Unlock()
+	return
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type registered struct {
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt *float64 `json:"exp"`
+	NotBefore *float64 `json:"nbf"`
+	IssuedAt  *float64 `json:"iat"`
+}
+
+// audience is a string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(b []byte) (err error) {
+	if bytes.HasPrefix(b, []byte("[")) {
+		return json.Unmarshal(b, (*[]string)(a))
+	}
+	var s string
+	if err = json.Unmarshal(b, &s); err != nil {
+		return
+	}
+	*a = audience{s}
+	return
+}
+
+// Verify verify the token signature and the registered claims, then decode
+// the claims into v.
+func (v *Verifier) Verify(token string, claims interface{}) (err error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrMalformed
+	}
+	var (
+		h       header
+		reg     registered
+		hb, pb  []byte
+		sig     []byte
+		matched bool
+	)
+	if hb, err = base64.RawURLEncoding.DecodeString(parts[0]); err != nil {
+		return ErrMalformed
+	}
+	if err = json.Unmarshal(hb, &h); err != nil {
+		return ErrMalformed
+	}
+	if !v.algs[h.Alg] {
+		return ErrAlgorithm
+	}
+	if sig, err = base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return ErrMalformed
+	}
+	signed := []byte(token[:len(parts[0])+1+len(parts[1])])
+	v.mutex.RLock()
+	keys := v.keys
+	v.mutex.RUnlock()
+	for _, k := range keys {
+		if (h.Kid != "" && k.ID != h.Kid) || !k.usable(h.Alg) {
+			continue
+		}
+		matched = true
+		if verify(h.Alg, k.key, signed, sig) {
+			goto verified
+		}
+	}
+	if !matched {
+		return ErrKeyNotFound
+	}
+	return ErrSignature
+verified:
+	if pb, err = base64.RawURLEncoding.DecodeString(parts[1]); err != nil {
+		return ErrMalformed
+	}
+	if err = json.Unmarshal(pb, &reg); err != nil {
+		return ErrMalformed
+	}
+	if err = v.validate(&reg, time.Now()); err != nil {
+		return
+	}
+	if err = json.Unmarshal(pb, claims); err != nil {
+		return ErrMalformed
+	}
+	return
+}
+
+func (v *Verifier) validate(reg *registered, now time.Time) error {
+	leeway := v.c.Leeway
+	if reg.ExpiresAt == nil {
+		if v.c.RequireExp {
+			return ErrExpired
+		}
+	} else if now.After(unix(*reg.ExpiresAt).Add(leeway)) {
+		return ErrExpired
+	}
+	if reg.NotBefore != nil && now.Add(leeway).Before(unix(*reg.NotBefore)) {
+		return ErrNotValidYet
+	}
+	if v.c.MaxAge > 0 {
+		if reg.IssuedAt == nil || now.After(unix(*reg.IssuedAt).Add(v.c.MaxAge+leeway)) {
+			return ErrExpired
+		}
+	}
+	if v.c.Issuer != "" && reg.Issuer != v.c.Issuer {
+		return ErrIssuer
+	}
+	if v.c.Audience != "" {
+		for _, aud := range reg.Audience {
+			if aud == v.c.Audience {
+				return nil
+			}
+		}
+		return ErrAudience
+	}
+	return nil
+}
+
+func unix(f float64) time.Time {
+	return time.Unix(0, int64(f*float64(time.Second)))
+}
+
+func verify(alg string, key interface{}, signed, sig []byte) bool {
+	hash := hashes[alg]
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write(signed)
+		return hmac.Equal(sig, mac.Sum(nil))
+	case *rsa.PublicKey:
+		h := hash.New()
+		h.Write(signed)
+		if alg[0] == 'P' {
+			return rsa.VerifyPSS(k, hash, h.Sum(nil), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthAuto}) == nil
+		}
+		return rsa.VerifyPKCS1v15(k, hash, h.Sum(nil), sig) == nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		h := hash.New()
+		h.Write(signed)
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, h.Sum(nil), r, s)
+	}
+	return false
+}
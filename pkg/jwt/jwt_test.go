@@ -0,0 +1,124 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type claims struct {
+	Mid    int64  `json:"mid"`
+	RoomID string `json:"room_id"`
+}
+
+func sign(t *testing.T, alg, kid string, key interface{}, payload map[string]interface{}) string {
+	h, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT", "kid": kid})
+	p, _ := json.Marshal(payload)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(p)
+	hash := hashes[alg]
+	d := hash.New()
+	d.Write([]byte(signed))
+	var (
+		sig []byte
+		err error
+	)
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(hash.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		if alg[0] == 'P' {
+			sig, err = rsa.SignPSS(rand.Reader, k, hash, d.Sum(nil), nil)
+		} else {
+			sig, err = rsa.SignPKCS1v15(rand.Reader, k, hash, d.Sum(nil))
+		}
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, d.Sum(nil))
+		size := (k.Curve.Params().BitSize + 7) / 8
+		sig = make([]byte, 2*size)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[size-len(rb):size], rb)
+		copy(sig[2*size-len(sb):], sb)
+	}
+	assert.Nil(t, err)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	var (
+		secret     = []byte("goim-secret")
+		rsaKey, _  = rsa.GenerateKey(rand.Reader, 2048)
+		ecKey, _   = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		pub, _     = x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+		now        = time.Now().Unix()
+		secretFile = filepath.Join(dir, "hmac.key")
+		pemFile    = filepath.Join(dir, "rsa.pem")
+		jwksFile   = filepath.Join(dir, "jwks.json")
+	)
+	assert.Nil(t, ioutil.WriteFile(secretFile, secret, 0644))
+	assert.Nil(t, ioutil.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}), 0644))
+	jwks := fmt.Sprintf(`{"keys":[{"kty":"EC","kid":"ec1","crv":"P-256","x":"%s","y":"%s"}]}`,
+		base64.RawURLEncoding.EncodeToString(ecKey.X.Bytes()), base64.RawURLEncoding.EncodeToString(ecKey.Y.Bytes()))
+	assert.Nil(t, ioutil.WriteFile(jwksFile, []byte(jwks), 0644))
+	v, err := NewVerifier(&Config{
+		Issuer:     "goim",
+		Audience:   "comet",
+		Algs:       []string{"HS256", "RS256", "PS256", "ES256"},
+		RequireExp: true,
+	}, []string{secretFile, pemFile, jwksFile})
+	assert.Nil(t, err)
+	payload := map[string]interface{}{"iss": "goim", "aud": []string{"comet"}, "exp": now + 60, "mid": 123, "room_id": "live://1000"}
+	for _, tc := range []struct {
+		alg, kid string
+		key      interface{}
+	}{
+		{"HS256", "hmac", secret},
+		{"HS256", "", secret},
+		{"RS256", "rsa", rsaKey},
+		{"PS256", "rsa", rsaKey},
+		{"ES256", "ec1", ecKey},
+	} {
+		var c claims
+		err = v.Verify(sign(t, tc.alg, tc.kid, tc.key, payload), &c)
+		assert.Nil(t, err, tc.alg)
+		assert.Equal(t, int64(123), c.Mid)
+		assert.Equal(t, "live://1000", c.RoomID)
+	}
+	var c claims
+	// wrong key, unknown kid and not allowed alg
+	assert.Equal(t, ErrSignature, v.Verify(sign(t, "HS256", "hmac", []byte("bad"), payload), &c))
+	assert.Equal(t, ErrKeyNotFound, v.Verify(sign(t, "HS256", "none", secret, payload), &c))
+	assert.Equal(t, ErrAlgorithm, v.Verify(sign(t, "HS512", "hmac", secret, payload), &c))
+	assert.Equal(t, ErrMalformed, v.Verify("a.b", &c))
+	// registered claims
+	assert.Equal(t, ErrExpired, v.Verify(sign(t, "HS256", "hmac", secret, map[string]interface{}{"iss": "goim", "aud": "comet", "exp": now - 60}), &c))
+	assert.Equal(t, ErrExpired, v.Verify(sign(t, "HS256", "hmac", secret, map[string]interface{}{"iss": "goim", "aud": "comet"}), &c))
+	assert.Equal(t, ErrNotValidYet, v.Verify(sign(t, "HS256", "hmac", secret, map[string]interface{}{"iss": "goim", "aud": "comet", "exp": now + 120, "nbf": now + 60}), &c))
+	assert.Equal(t, ErrIssuer, v.Verify(sign(t, "HS256", "hmac", secret, map[string]interface{}{"iss": "other", "aud": "comet", "exp": now + 60}), &c))
+	assert.Equal(t, ErrAudience, v.Verify(sign(t, "HS256", "hmac", secret, map[string]interface{}{"iss": "goim", "aud": "logic", "exp": now + 60}), &c))
+	// rotate
+	assert.Nil(t, ioutil.WriteFile(secretFile, []byte("new-secret"), 0644))
+	assert.Nil(t, v.Reload())
+	assert.Equal(t, ErrSignature, v.Verify(sign(t, "HS256", "hmac", secret, payload), &c))
+	assert.Nil(t, v.Verify(sign(t, "HS256", "hmac", []byte("new-secret"), payload), &c))
+}
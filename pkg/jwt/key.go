@@ -0,0 +1,212 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey invalid key file
+var ErrInvalidKey = errors.New("jwt: invalid key")
+
+// Key is a verification key.
+type Key struct {
+	ID  string // kid
+	Alg string // the only algorithm of the key if not empty
+	key interface{}
+}
+
+// usable reports whether the key can verify the algorithm.
+func (k *Key) usable(alg string) bool {
+	if k.Alg != "" && k.Alg != alg {
+		return false
+	}
+	switch key := k.key.(type) {
+	case []byte:
+		return alg[0] == 'H'
+	case *rsa.PublicKey:
+		return alg[0] == 'R' || alg[0] == 'P'
+	case *ecdsa.PublicKey:
+		switch key.Curve.Params().BitSize {
+		case 256:
+			return alg == "ES256"
+		case 384:
+			return alg == "ES384"
+		case 521:
+			return alg == "ES512"
+		}
+	}
+	return false
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// oct
+	K string `json:"k"`
+}
+
+// LoadKeys load the keys from files, a file is one of the JWKS or JWK json,
+// the PEM public keys or certificates, or a raw HMAC secret. The kid of the
+// PEM and secret keys is the file name without extension.
+func LoadKeys(files ...string) (keys []*Key, err error) {
+	var (
+		b  []byte
+		ks []*Key
+	)
+	for _, file := range files {
+		if b, err = ioutil.ReadFile(file); err != nil {
+			return
+		}
+		id := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+		if ks, err = ParseKeys(id, b); err != nil {
+			return
+		}
+		keys = append(keys, ks...)
+	}
+	return
+}
+
+// ParseKeys parse the keys of a file content, id is the default kid.
+func ParseKeys(id string, b []byte) (keys []*Key, err error) {
+	b = bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(b, []byte("{")):
+		return parseJWKS(b)
+	case bytes.HasPrefix(b, []byte("-----BEGIN")):
+		return parsePEM(id, b)
+	case len(b) > 0:
+		return []*Key{{ID: id, key: b}}, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+func parseJWKS(b []byte) (keys []*Key, err error) {
+	var set struct {
+		Keys []*jwk `json:"keys"`
+	}
+	if err = json.Unmarshal(b, &set); err != nil {
+		return nil, ErrInvalidKey
+	}
+	if set.Keys == nil {
+		// a single jwk
+		k := new(jwk)
+		if err = json.Unmarshal(b, k); err != nil {
+			return nil, ErrInvalidKey
+		}
+		set.Keys = []*jwk{k}
+	}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		if key, err = k.publicKey(); err != nil {
+			return
+		}
+		keys = append(keys, &Key{ID: k.Kid, Alg: k.Alg, key: key})
+	}
+	return
+}
+
+func (k *jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err1 := decodeInt(k.N)
+		e, err2 := decodeInt(k.E)
+		if err1 != nil || err2 != nil || !e.IsInt64() {
+			return nil, ErrInvalidKey
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrInvalidKey
+		}
+		x, err1 := decodeInt(k.X)
+		y, err2 := decodeInt(k.Y)
+		if err1 != nil || err2 != nil || !curve.IsOnCurve(x, y) {
+			return nil, ErrInvalidKey
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		b, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil || len(b) == 0 {
+			return nil, ErrInvalidKey
+		}
+		return b, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func parsePEM(id string, b []byte) (keys []*Key, err error) {
+	var (
+		block *pem.Block
+		key   interface{}
+		cert  *x509.Certificate
+	)
+	for {
+		if block, b = pem.Decode(b); block == nil {
+			break
+		}
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			if cert, err = x509.ParseCertificate(block.Bytes); err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, ErrInvalidKey
+		}
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+		default:
+			return nil, ErrInvalidKey
+		}
+		keys = append(keys, &Key{ID: id, key: key})
+	}
+	if len(keys) == 0 {
+		return nil, ErrInvalidKey
+	}
+	return
+}
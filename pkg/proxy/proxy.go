@@ -0,0 +1,218 @@
+// Package proxy implements the receiver side of the HAProxy PROXY protocol
+// version 1 and 2, see https://www.haproxy.org/download/2.0/doc/proxy-protocol.txt.
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	v1MaxLength = 107
+	v2HeadSize  = 16
+	bufSize     = 256
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	// ErrInvalidHeader invalid proxy protocol header
+	ErrInvalidHeader = errors.New("proxy: invalid header")
+	// ErrInvalidCIDR invalid trusted cidr
+	ErrInvalidCIDR = errors.New("proxy: invalid trusted cidr")
+	// ErrNoTrusted no trusted cidr
+	ErrNoTrusted = errors.New("proxy: no trusted cidr")
+)
+
+// Policy decides which connections must send a PROXY protocol header.
+type Policy struct {
+	trusted []*net.IPNet
+	timeout time.Duration
+}
+
+// NewPolicy new a policy, connections from the trusted networks must send a
+// header in timeout, at least one trusted network is required.
+func NewPolicy(trusted []string, timeout time.Duration) (p *Policy, err error) {
+	var ipnet *net.IPNet
+	if len(trusted) == 0 {
+		return nil, ErrNoTrusted
+	}
+	p = &Policy{timeout: timeout}
+	for _, cidr := range trusted {
+		if _, ipnet, err = net.ParseCIDR(cidr); err != nil {
+			return nil, ErrInvalidCIDR
+		}
+		p.trusted = append(p.trusted, ipnet)
+	}
+	return
+}
+
+// Trusted reports whether the address is in the trusted networks.
+func (p *Policy) Trusted(addr net.Addr) bool {
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	for _, ipnet := range p.trusted {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Wrap returns a Conn reading the header if c comes from the trusted networks,
+// otherwise returns c itself.
+func (p *Policy) Wrap(c net.Conn) net.Conn {
+	if !p.Trusted(c.RemoteAddr()) {
+		return c
+	}
+	return &Conn{Conn: c, timeout: p.timeout}
+}
+
+// Conn is a net.Conn which reads the PROXY protocol header on the first Read
+// or RemoteAddr, and reports the source address of the header.
+type Conn struct {
+	net.Conn
+	r       *bufio.Reader
+	timeout time.Duration
+	once    sync.Once
+	src     net.Addr
+	err     error
+}
+
+// Read reads data after the header.
+func (c *Conn) Read(b []byte) (int, error) {
+	c.once.Do(c.readHeader)
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.r != nil {
+		if c.r.Buffered() > 0 {
+			return c.r.Read(b)
+		}
+		c.r = nil
+	}
+	return c.Conn.Read(b)
+}
+
+// RemoteAddr returns the source address of the header, or the remote address
+// of the connection if the header is local or unknown.
+func (c *Conn) RemoteAddr() net.Addr {
+	c.once.Do(c.readHeader)
+	if c.src != nil {
+		return c.src
+	}
+	return c.Conn.RemoteAddr()
+}
+
+func (c *Conn) readHeader() {
+	if c.timeout > 0 {
+		_ = c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		defer c.Conn.SetReadDeadline(time.Time{})
+	}
+	c.r = bufio.NewReaderSize(c.Conn, bufSize)
+	c.src, c.err = ReadHeader(c.r)
+}
+
+// ReadHeader reads a version 1 or 2 header and returns the source address,
+// which is nil for local or unknown connections.
+func ReadHeader(r *bufio.Reader) (src net.Addr, err error) {
+	var b []byte
+	if b, err = r.Peek(len(v1Prefix)); err != nil {
+		return
+	}
+	if bytes.Equal(b, v1Prefix) {
+		return readV1(r)
+	}
+	if b, err = r.Peek(len(v2Signature)); err != nil {
+		return
+	}
+	if bytes.Equal(b, v2Signature) {
+		return readV2(r)
+	}
+	return nil, ErrInvalidHeader
+}
+
+// readV1 reads a header like "PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n".
+func readV1(r *bufio.Reader) (src net.Addr, err error) {
+	var line []byte
+	if line, err = r.ReadSlice('\n'); err != nil {
+		if err == bufio.ErrBufferFull {
+			err = ErrInvalidHeader
+		}
+		return
+	}
+	if len(line) > v1MaxLength || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrInvalidHeader
+	}
+	fields := strings.Fields(string(line[:len(line)-2]))
+	if len(fields) < 2 {
+		return nil, ErrInvalidHeader
+	}
+	switch fields[1] {
+	case "UNKNOWN":
+		return nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, ErrInvalidHeader
+	}
+	if len(fields) != 6 {
+		return nil, ErrInvalidHeader
+	}
+	ip := net.ParseIP(fields[2])
+	port, perr := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || perr != nil || net.ParseIP(fields[3]) == nil {
+		return nil, ErrInvalidHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readV2 reads a binary header.
+func readV2(r *bufio.Reader) (src net.Addr, err error) {
+	var (
+		head = make([]byte, v2HeadSize)
+		body []byte
+	)
+	if _, err = io.ReadFull(r, head); err != nil {
+		return
+	}
+	if head[12]>>4 != 2 {
+		return nil, ErrInvalidHeader
+	}
+	body = make([]byte, binary.BigEndian.Uint16(head[14:]))
+	if _, err = io.ReadFull(r, body); err != nil {
+		return
+	}
+	switch head[12] & 0x0f {
+	case 0x0: // LOCAL
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, ErrInvalidHeader
+	}
+	switch head[13] {
+	case 0x11, 0x12: // TCP/UDP over IPv4
+		if len(body) < 12 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:4]), Port: int(binary.BigEndian.Uint16(body[8:]))}, nil
+	case 0x21, 0x22: // TCP/UDP over IPv6
+		if len(body) < 36 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{IP: net.IP(body[0:16]), Port: int(binary.BigEndian.Uint16(body[32:]))}, nil
+	}
+	// UNSPEC or unix sockets
+	return nil, nil
+}
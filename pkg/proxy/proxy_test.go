@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadHeaderV1(t *testing.T) {
+	src, err := ReadHeader(bufio.NewReader(bytes.NewBufferString("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\nGET")))
+	assert.Nil(t, err)
+	assert.Equal(t, "192.168.0.1:56324", src.String())
+	src, err = ReadHeader(bufio.NewReader(bytes.NewBufferString("PROXY TCP6 ::1 ::1 56324 443\r\n")))
+	assert.Nil(t, err)
+	assert.Equal(t, "[::1]:56324", src.String())
+	src, err = ReadHeader(bufio.NewReader(bytes.NewBufferString("PROXY UNKNOWN\r\n")))
+	assert.Nil(t, err)
+	assert.Nil(t, src)
+	_, err = ReadHeader(bufio.NewReader(bytes.NewBufferString("PROXY TCP4 192.168.0.1\r\n")))
+	assert.Equal(t, ErrInvalidHeader, err)
+	_, err = ReadHeader(bufio.NewReader(bytes.NewBufferString("GET /sub HTTP/1.1\r\n")))
+	assert.Equal(t, ErrInvalidHeader, err)
+}
+
+func TestReadHeaderV2(t *testing.T) {
+	var (
+		head = append([]byte{}, v2Signature...)
+		buf  bytes.Buffer
+	)
+	// PROXY TCP4 with a TLV
+	buf.Write(head)
+	buf.Write([]byte{0x21, 0x11, 0x00, 0x0f, 10, 0, 0, 1, 10, 0, 0, 2, 0x1f, 0x90, 0x01, 0xbb, 0x04, 0x00, 0x00})
+	src, err := ReadHeader(bufio.NewReader(&buf))
+	assert.Nil(t, err)
+	assert.Equal(t, "10.0.0.1:8080", src.String())
+	// LOCAL
+	buf.Reset()
+	buf.Write(head)
+	buf.Write([]byte{0x20, 0x00, 0x00, 0x00})
+	src, err = ReadHeader(bufio.NewReader(&buf))
+	assert.Nil(t, err)
+	assert.Nil(t, src)
+	// bad version
+	buf.Reset()
+	buf.Write(head)
+	buf.Write([]byte{0x11, 0x11, 0x00, 0x00})
+	_, err = ReadHeader(bufio.NewReader(&buf))
+	assert.Equal(t, ErrInvalidHeader, err)
+}
+
+func TestPolicy(t *testing.T) {
+	p, err := NewPolicy([]string{"127.0.0.0/8"}, time.Second)
+	assert.Nil(t, err)
+	assert.True(t, p.Trusted(&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 80}))
+	assert.False(t, p.Trusted(&net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 80}))
+	_, err = NewPolicy([]string{"127.0.0.1"}, time.Second)
+	assert.Equal(t, ErrInvalidCIDR, err)
+	_, err = NewPolicy(nil, time.Second)
+	assert.Equal(t, ErrNoTrusted, err)
+	// conn
+	cli, srv := net.Pipe()
+	go func() {
+		_, _ = cli.Write([]byte("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\nhello"))
+		cli.Close()
+	}()
+	conn := &Conn{Conn: srv}
+	assert.Equal(t, "192.168.0.1:56324", conn.RemoteAddr().String())
+	b, err := ioutil.ReadAll(conn)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+}
@@ -0,0 +1,233 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	xtime "github.com/Terry-Mao/goim/pkg/time"
+	log "github.com/golang/glog"
+)
+
+// exporters.
+const (
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// Config is trace config, tracing is disabled if the exporter is empty.
+type Config struct {
+	Exporter string         // stdout or otlp
+	Endpoint string         // otlp http endpoint, e.g. http://127.0.0.1:4318/v1/traces
+	Ratio    float64        // sample ratio of the new traces
+	Batch    int            // max spans per export
+	Interval xtime.Duration // max delay of an export
+	Timeout  xtime.Duration // otlp request timeout
+}
+
+var _tracer *tracer
+
+type tracer struct {
+	c       *Config
+	service string
+	w       io.Writer
+	client  *http.Client
+	spans   chan *Span
+	dropped uint64
+	once    sync.Once
+	wg      sync.WaitGroup
+}
+
+// Init start the exporter of the service, tracing is disabled if the config
+// is nil or the exporter is empty.
+func Init(c *Config, service string) {
+	if c == nil || c.Exporter == "" {
+		return
+	}
+	if c.Batch <= 0 {
+		c.Batch = 256
+	}
+	if c.Interval <= 0 {
+		c.Interval = xtime.Duration(time.Second)
+	}
+	t := &tracer{
+		c:       c,
+		service: service,
+		w:       os.Stdout,
+		client:  &http.Client{Timeout: time.Duration(c.Timeout)},
+		spans:   make(chan *Span, c.Batch*4),
+	}
+	switch c.Exporter {
+	case ExporterStdout, ExporterOTLP:
+	default:
+		log.Errorf("unknown trace exporter: %s, use %s", c.Exporter, ExporterStdout)
+		c.Exporter = ExporterStdout
+	}
+	t.wg.Add(1)
+	go t.exportproc()
+	_tracer = t
+}
+
+// Close flush the pending spans and stop the exporter.
+func Close() {
+	t := _tracer
+	if t == nil {
+		return
+	}
+	t.once.Do(func() {
+		close(t.spans)
+	})
+	t.wg.Wait()
+}
+
+func (t *tracer) sample() bool {
+	return t.c.Ratio >= 1 || (t.c.Ratio > 0 && rand.Float64() < t.c.Ratio)
+}
+
+// export queue the finished span, drop it if the queue is full.
+func (t *tracer) export(s *Span) {
+	defer func() {
+		// the tracer is closed
+		_ = recover()
+	}()
+	select {
+	case t.spans <- s:
+	default:
+		if n := atomic.AddUint64(&t.dropped, 1); n%1000 == 1 {
+			log.Errorf("trace export queue full, dropped %d spans", n)
+		}
+	}
+}
+
+// exportproc export the spans in batch.
+func (t *tracer) exportproc() {
+	defer t.wg.Done()
+	var (
+		batch  = make([]*Span, 0, t.c.Batch)
+		ticker = time.NewTicker(time.Duration(t.c.Interval))
+	)
+	defer ticker.Stop()
+	for {
+		select {
+		case s, ok := <-t.spans:
+			if !ok {
+				t.flush(batch)
+				return
+			}
+			if batch = append(batch, s); len(batch) < t.c.Batch {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+		t.flush(batch)
+		batch = batch[:0]
+	}
+}
+
+func (t *tracer) flush(batch []*Span) {
+	if len(batch) == 0 {
+		return
+	}
+	b, err := json.Marshal(encode(t.service, batch))
+	if err != nil {
+		log.Errorf("json.Marshal() error(%v)", err)
+		return
+	}
+	if t.c.Exporter == ExporterStdout {
+		if _, err = t.w.Write(append(b, '\n')); err != nil {
+			log.Errorf("trace write error(%v)", err)
+		}
+		return
+	}
+	resp, err := t.client.Post(t.c.Endpoint, "application/json", bytes.NewReader(b))
+	if err != nil {
+		log.Errorf("trace export(%s) error(%v)", t.c.Endpoint, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Errorf("trace export(%s) status(%d)", t.c.Endpoint, resp.StatusCode)
+	}
+}
+
+// OTLP/JSON trace request.
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpAttr `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string     `json:"traceId"`
+		SpanID            string     `json:"spanId"`
+		ParentSpanID      string     `json:"parentSpanId,omitempty"`
+		Name              string     `json:"name"`
+		Kind              Kind       `json:"kind"`
+		StartTimeUnixNano string     `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+		Attributes        []otlpAttr `json:"attributes,omitempty"`
+		Status            otlpStatus `json:"status"`
+	}
+	otlpAttr struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"` // 2 is error
+		Message string `json:"message,omitempty"`
+	}
+)
+
+func encode(service string, batch []*Span) *otlpRequest {
+	spans := make([]otlpSpan, 0, len(batch))
+	for _, s := range batch {
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.ctx.TraceID[:]),
+			SpanID:            hex.EncodeToString(s.ctx.SpanID[:]),
+			Name:              s.name,
+			Kind:              s.kind,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parent != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parent[:])
+		}
+		for _, a := range s.attrs {
+			span.Attributes = append(span.Attributes, otlpAttr{Key: a.key, Value: otlpValue{StringValue: a.value}})
+		}
+		if s.err != "" {
+			span.Status = otlpStatus{Code: 2, Message: s.err}
+		}
+		spans = append(spans, span)
+	}
+	return &otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: []otlpAttr{{Key: "service.name", Value: otlpValue{StringValue: service}}}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "goim"}, Spans: spans}},
+	}}}
+}
@@ -0,0 +1,160 @@
+// Package trace records the spans of a push across the services, the span
+// context is propagated as a W3C traceparent and the spans are exported in
+// the OTLP/JSON format, see https://www.w3.org/TR/trace-context and
+// https://opentelemetry.io/docs/specs/otlp.
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Kind is the span kind of OTLP.
+type Kind int
+
+// span kinds.
+const (
+	KindInternal Kind = 1
+	KindServer   Kind = 2
+	KindClient   Kind = 3
+	KindProducer Kind = 4
+	KindConsumer Kind = 5
+)
+
+// SpanContext identifies a span in a trace.
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid reports whether the trace id and span id are not zero.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// String format the span context as a sampled traceparent.
+func (sc SpanContext) String() string {
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-01"
+}
+
+// Parse parse a traceparent, only the sampled ones are valid.
+func Parse(traceparent string) (sc SpanContext, ok bool) {
+	parts := strings.Split(traceparent, "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || flags[0]&0x01 == 0 {
+		return
+	}
+	return sc, sc.IsValid()
+}
+
+type attr struct {
+	key   string
+	value string
+}
+
+// Span is a timed operation of a trace, a nil span is a no-op.
+type Span struct {
+	name   string
+	kind   Kind
+	ctx    SpanContext
+	parent [8]byte
+	start  time.Time
+	end    time.Time
+	attrs  []attr
+	err    string
+}
+
+// Start start a span continued from the parent traceparent, it returns nil
+// if tracing is disabled or the parent is invalid (not traced).
+func Start(name string, kind Kind, parent string) *Span {
+	if _tracer == nil {
+		return nil
+	}
+	psc, ok := Parse(parent)
+	if !ok {
+		return nil
+	}
+	return newSpan(name, kind, psc.TraceID, psc.SpanID)
+}
+
+// StartRoot start a span continued from the parent traceparent, a new trace
+// is started by the sample ratio if the parent is invalid, it returns nil if
+// tracing is disabled or the new trace is not sampled.
+func StartRoot(name string, kind Kind, parent string) *Span {
+	t := _tracer
+	if t == nil {
+		return nil
+	}
+	if psc, ok := Parse(parent); ok {
+		return newSpan(name, kind, psc.TraceID, psc.SpanID)
+	}
+	if !t.sample() {
+		return nil
+	}
+	var traceID [16]byte
+	_, _ = rand.Read(traceID[:])
+	return newSpan(name, kind, traceID, [8]byte{})
+}
+
+func newSpan(name string, kind Kind, traceID [16]byte, parent [8]byte) (s *Span) {
+	s = &Span{name: name, kind: kind, parent: parent, start: time.Now()}
+	s.ctx.TraceID = traceID
+	_, _ = rand.Read(s.ctx.SpanID[:])
+	return
+}
+
+// SetAttr set an attribute of the span.
+func (s *Span) SetAttr(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.attrs = append(s.attrs, attr{key: key, value: fmt.Sprint(value)})
+}
+
+// Traceparent get the traceparent of the span, empty if nil.
+func (s *Span) Traceparent() string {
+	if s == nil {
+		return ""
+	}
+	return s.ctx.String()
+}
+
+// Finish end the span with the error if any and export it.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.end = time.Now()
+	if err != nil {
+		s.err = err.Error()
+	}
+	if t := _tracer; t != nil {
+		t.export(s)
+	}
+}
+
+type spanKey struct{}
+
+// NewContext return a context carrying the span.
+func NewContext(ctx context.Context, s *Span) context.Context {
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// FromContext get the span of the context, nil if none.
+func FromContext(ctx context.Context) (s *Span) {
+	s, _ = ctx.Value(spanKey{}).(*Span)
+	return
+}
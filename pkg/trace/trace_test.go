@@ -0,0 +1,65 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tp := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, ok := Parse(tp)
+	assert.True(t, ok)
+	assert.Equal(t, tp, sc.String())
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", // not sampled
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01", // zero trace id
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47-00f067aa0ba902b7-01",
+		"00-xbf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, ok = Parse(s)
+		assert.False(t, ok, s)
+	}
+}
+
+func TestSpan(t *testing.T) {
+	// disabled
+	s := StartRoot("disabled", KindServer, "")
+	assert.Nil(t, s)
+	s.SetAttr("key", "value")
+	s.Finish(nil)
+	assert.Equal(t, "", s.Traceparent())
+	assert.Nil(t, FromContext(context.Background()))
+
+	Init(&Config{Exporter: ExporterStdout, Ratio: 1}, "goim.test")
+	buf := new(bytes.Buffer)
+	_tracer.w = buf
+	assert.Nil(t, Start("untraced", KindServer, ""))
+	root := StartRoot("root", KindServer, "")
+	ctx := NewContext(context.Background(), root)
+	assert.Equal(t, root, FromContext(ctx))
+	child := Start("child", KindProducer, FromContext(ctx).Traceparent())
+	child.SetAttr("op", 1000)
+	child.Finish(errors.New("kafka down"))
+	root.Finish(nil)
+	Close()
+
+	var req otlpRequest
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &req))
+	assert.Equal(t, "goim.test", req.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, spans[1].TraceID, spans[0].TraceID)
+	assert.Equal(t, spans[1].SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, "", spans[1].ParentSpanID)
+	assert.Equal(t, "1000", spans[0].Attributes[0].Value.StringValue)
+	assert.Equal(t, 2, spans[0].Status.Code)
+	assert.Equal(t, KindProducer, spans[0].Kind)
+}
@@ -61,6 +61,12 @@ type Conn struct {
 	r       *bufio.Reader
 	w       *bufio.Writer
 	maskKey []byte
+	// permessage-deflate
+	deflater *deflater
+	inflater *inflater
+	pending  bool   // a data message is buffered for compression
+	msgType  int    // pending message type
+	msg      []byte // pending message payload
 }
 
 // new connection
@@ -78,14 +84,28 @@ func (c *Conn) WriteMessage(msgType int, msg []byte) (err error) {
 }
 
 // WriteHeader write header frame.
+// If permessage-deflate is negotiated, data messages are buffered and
+// compressed on the next WriteHeader or Flush.
 func (c *Conn) WriteHeader(msgType int, length int) (err error) {
+	if c.deflater != nil && (msgType == TextMessage || msgType == BinaryMessage) {
+		if err = c.flushMessage(); err != nil {
+			return
+		}
+		c.pending = true
+		c.msgType = msgType
+		c.msg = c.msg[:0]
+		return
+	}
+	return c.writeHeader(finBit|byte(msgType), length)
+}
+
+func (c *Conn) writeHeader(b0 byte, length int) (err error) {
 	var h []byte
 	if h, err = c.w.Peek(2); err != nil {
 		return
 	}
 	// 1.First byte. FIN/RSV1/RSV2/RSV3/OpCode(4bits)
-	h[0] = 0
-	h[0] |= finBit | byte(msgType)
+	h[0] = b0
 	// 2.Second byte. Mask/Payload len(7bits)
 	h[1] = 0
 	switch {
@@ -112,6 +132,10 @@ func (c *Conn) WriteHeader(msgType int, length int) (err error) {
 
 // WriteBody write a message body.
 func (c *Conn) WriteBody(b []byte) (err error) {
+	if c.pending {
+		c.msg = append(c.msg, b...)
+		return
+	}
 	if len(b) > 0 {
 		_, err = c.w.Write(b)
 	}
@@ -120,30 +144,63 @@ func (c *Conn) WriteBody(b []byte) (err error) {
 
 // Peek write peek.
 func (c *Conn) Peek(n int) ([]byte, error) {
+	if c.pending {
+		l := len(c.msg)
+		if cap(c.msg)-l < n {
+			msg := make([]byte, l, 2*cap(c.msg)+n)
+			copy(msg, c.msg)
+			c.msg = msg
+		}
+		c.msg = c.msg[:l+n]
+		return c.msg[l:], nil
+	}
 	return c.w.Peek(n)
 }
 
 // Flush flush writer buffer
-func (c *Conn) Flush() error {
+func (c *Conn) Flush() (err error) {
+	if err = c.flushMessage(); err != nil {
+		return
+	}
 	return c.w.Flush()
 }
 
+// flushMessage compress the pending message and write it as one frame.
+func (c *Conn) flushMessage() (err error) {
+	var b []byte
+	if !c.pending {
+		return
+	}
+	c.pending = false
+	if b, err = c.deflater.compress(c.msg); err != nil {
+		return
+	}
+	if err = c.writeHeader(finBit|rsv1Bit|byte(c.msgType), len(b)); err != nil {
+		return
+	}
+	_, err = c.w.Write(b)
+	return
+}
+
 // ReadMessage read a message.
 func (c *Conn) ReadMessage() (op int, payload []byte, err error) {
 	var (
-		fin         bool
-		finOp, n    int
-		partPayload []byte
+		fin, compressed bool
+		finOp, n        int
+		partPayload     []byte
 	)
 	for {
 		// read frame
-		if fin, op, partPayload, err = c.readFrame(); err != nil {
+		if fin, compressed, op, partPayload, err = c.readFrame(compressed); err != nil {
 			return
 		}
 		switch op {
 		case BinaryMessage, TextMessage, continuationFrame:
 			if fin && len(payload) == 0 {
-				return op, partPayload, nil
+				if compressed {
+					partPayload, err = c.inflater.decompress(partPayload)
+				}
+				return op, partPayload, err
 			}
 			// continuation frame
 			payload = append(payload, partPayload...)
@@ -153,6 +210,9 @@ func (c *Conn) ReadMessage() (op int, payload []byte, err error) {
 			// final frame
 			if fin {
 				op = finOp
+				if compressed {
+					payload, err = c.inflater.decompress(payload)
+				}
 				return
 			}
 		case PingMessage:
@@ -178,7 +238,9 @@ func (c *Conn) ReadMessage() (op int, payload []byte, err error) {
 	}
 }
 
-func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
+// readFrame read a frame, compressed reports whether the message it belongs
+// to is compressed, which is only marked in the first frame.
+func (c *Conn) readFrame(inCompressed bool) (fin, compressed bool, op int, payload []byte, err error) {
 	var (
 		b          byte
 		p          []byte
@@ -193,12 +255,21 @@ func (c *Conn) readFrame() (fin bool, op int, payload []byte, err error) {
 	}
 	// final frame
 	fin = (b & finBit) != 0
-	// rsv MUST be 0
-	if rsv := b & (rsv1Bit | rsv2Bit | rsv3Bit); rsv != 0 {
-		return false, 0, nil, fmt.Errorf("unexpected reserved bits rsv1=%d, rsv2=%d, rsv3=%d", b&rsv1Bit, b&rsv2Bit, b&rsv3Bit)
-	}
 	// op code
 	op = int(b & opBit)
+	// rsv1 marks a compressed message if permessage-deflate is negotiated
+	compressed = inCompressed
+	rsv := b & (rsv1Bit | rsv2Bit | rsv3Bit)
+	if rsv == rsv1Bit && c.inflater != nil && (op == TextMessage || op == BinaryMessage) {
+		compressed = true
+		rsv = 0
+	} else if op == TextMessage || op == BinaryMessage {
+		compressed = false
+	}
+	// other rsv MUST be 0
+	if rsv != 0 {
+		return false, false, 0, nil, fmt.Errorf("unexpected reserved bits rsv1=%d, rsv2=%d, rsv3=%d", b&rsv1Bit, b&rsv2Bit, b&rsv3Bit)
+	}
 	// 2.Second byte. Mask/Payload len(7bits)
 	b, err = c.r.ReadByte()
 	if err != nil {
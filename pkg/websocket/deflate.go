@@ -0,0 +1,212 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+)
+
+const (
+	// permessage-deflate extension, see RFC 7692.
+	deflateExtension        = "permessage-deflate"
+	serverNoContextTakeover = "server_no_context_takeover"
+	clientNoContextTakeover = "client_no_context_takeover"
+	serverMaxWindowBits     = "server_max_window_bits"
+	clientMaxWindowBits     = "client_max_window_bits"
+
+	maxWindowSize  = 1 << 15
+	maxInflateSize = 1 << 16 // default max inflated message size
+)
+
+var (
+	// ErrMessageTooLarge inflated message too large
+	ErrMessageTooLarge = errors.New("inflated message too large")
+
+	// deflateTail is stripped from every compressed message, see RFC 7692 7.2.1.
+	deflateTail = []byte{0x00, 0x00, 0xff, 0xff}
+	// inflateTail restores the stripped tail and appends an empty final block.
+	inflateTail = []byte{0x00, 0x00, 0xff, 0xff, 0x01, 0x00, 0x00, 0xff, 0xff}
+
+	flateWriterPools [flate.BestCompression - flate.HuffmanOnly + 1]sync.Pool
+	flateReaderPool  sync.Pool
+)
+
+// DeflateConfig is the permessage-deflate extension config.
+type DeflateConfig struct {
+	// Level is the compress/flate compression level.
+	Level int
+	// ServerNoContextTakeover resets the compressor for every message,
+	// which allows sharing compressors between connections.
+	ServerNoContextTakeover bool
+	// ClientNoContextTakeover asks clients to reset their compressor for
+	// every message, so the server needn't keep a window per connection.
+	ClientNoContextTakeover bool
+	// MaxInflateSize is the max size of an inflated message, 64K if zero.
+	MaxInflateSize int
+}
+
+// negotiateDeflate accepts the first permessage-deflate offer the server can
+// honor and returns the Sec-WebSocket-Extensions response value.
+func negotiateDeflate(offers []string, c *DeflateConfig) (resp string, d *deflater, f *inflater) {
+	for _, offer := range strings.Split(strings.Join(offers, ","), ",") {
+		var (
+			ok          = true
+			serverNoCtx = c.ServerNoContextTakeover
+			clientNoCtx = c.ClientNoContextTakeover
+			params      = strings.Split(offer, ";")
+		)
+		if strings.TrimSpace(params[0]) != deflateExtension {
+			continue
+		}
+		for _, param := range params[1:] {
+			name, value := splitParam(param)
+			switch name {
+			case serverNoContextTakeover:
+				serverNoCtx = true
+			case clientNoContextTakeover:
+				clientNoCtx = true
+			case serverMaxWindowBits:
+				// compress/flate always uses a 32K window
+				ok = value == "15"
+			case clientMaxWindowBits:
+			default:
+				ok = false
+			}
+			if !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		resp = deflateExtension
+		if serverNoCtx {
+			resp += "; " + serverNoContextTakeover
+		}
+		if clientNoCtx {
+			resp += "; " + clientNoContextTakeover
+		}
+		d = &deflater{level: c.Level, noContext: serverNoCtx}
+		f = &inflater{noContext: clientNoCtx, max: c.MaxInflateSize}
+		return
+	}
+	return
+}
+
+func splitParam(param string) (name, value string) {
+	param = strings.TrimSpace(param)
+	if i := strings.IndexByte(param, '='); i >= 0 {
+		name = strings.TrimSpace(param[:i])
+		value = strings.Trim(strings.TrimSpace(param[i+1:]), `"`)
+		return
+	}
+	return param, ""
+}
+
+// deflater compresses outgoing messages.
+type deflater struct {
+	level     int
+	noContext bool
+	fw        *flate.Writer // only used with context takeover
+	buf       bytes.Buffer
+}
+
+func (d *deflater) compress(msg []byte) (b []byte, err error) {
+	var fw *flate.Writer
+	d.buf.Reset()
+	if d.noContext {
+		if fw, err = getFlateWriter(&d.buf, d.level); err != nil {
+			return
+		}
+		defer putFlateWriter(fw, d.level)
+	} else {
+		if d.fw == nil {
+			if d.fw, err = flate.NewWriter(&d.buf, d.level); err != nil {
+				return
+			}
+		}
+		fw = d.fw
+	}
+	if _, err = fw.Write(msg); err != nil {
+		return
+	}
+	if err = fw.Flush(); err != nil {
+		return
+	}
+	b = d.buf.Bytes()
+	if bytes.HasSuffix(b, deflateTail) {
+		b = b[:len(b)-len(deflateTail)]
+	}
+	return
+}
+
+func getFlateWriter(w io.Writer, level int) (fw *flate.Writer, err error) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return flate.NewWriter(w, level)
+	}
+	if v := flateWriterPools[level-flate.HuffmanOnly].Get(); v != nil {
+		fw = v.(*flate.Writer)
+		fw.Reset(w)
+		return
+	}
+	return flate.NewWriter(w, level)
+}
+
+func putFlateWriter(fw *flate.Writer, level int) {
+	if level < flate.HuffmanOnly || level > flate.BestCompression {
+		return
+	}
+	flateWriterPools[level-flate.HuffmanOnly].Put(fw)
+}
+
+// inflater decompresses incoming messages.
+type inflater struct {
+	noContext bool
+	max       int           // max inflated message size
+	fr        io.ReadCloser // only used with context takeover
+	dict      []byte
+}
+
+func (f *inflater) decompress(payload []byte) (b []byte, err error) {
+	var (
+		fr  io.ReadCloser
+		max = f.max
+		r   = io.MultiReader(bytes.NewReader(payload), bytes.NewReader(inflateTail))
+	)
+	if max <= 0 {
+		max = maxInflateSize
+	}
+	if f.noContext {
+		if v := flateReaderPool.Get(); v != nil {
+			fr = v.(io.ReadCloser)
+			_ = fr.(flate.Resetter).Reset(r, nil)
+		} else {
+			fr = flate.NewReader(r)
+		}
+		defer flateReaderPool.Put(fr)
+	} else {
+		if f.fr == nil {
+			f.fr = flate.NewReaderDict(r, f.dict)
+		} else {
+			_ = f.fr.(flate.Resetter).Reset(r, f.dict)
+		}
+		fr = f.fr
+	}
+	if b, err = ioutil.ReadAll(io.LimitReader(fr, int64(max)+1)); err != nil {
+		return
+	}
+	if len(b) > max {
+		return nil, ErrMessageTooLarge
+	}
+	if !f.noContext {
+		f.dict = append(f.dict, b...)
+		if len(f.dict) > maxWindowSize {
+			f.dict = f.dict[len(f.dict)-maxWindowSize:]
+		}
+	}
+	return
+}
@@ -0,0 +1,75 @@
+package websocket
+
+import (
+	"bytes"
+	"compress/flate"
+	"strings"
+	"testing"
+
+	"github.com/Terry-Mao/goim/pkg/bufio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateDeflate(t *testing.T) {
+	c := &DeflateConfig{Level: flate.BestSpeed}
+	resp, d, f := negotiateDeflate([]string{"permessage-deflate; client_max_window_bits"}, c)
+	assert.Equal(t, "permessage-deflate", resp)
+	assert.False(t, d.noContext)
+	assert.False(t, f.noContext)
+	// server can not limit the window, fallback to the next offer
+	resp, d, f = negotiateDeflate([]string{"permessage-deflate; server_max_window_bits=10, permessage-deflate; server_no_context_takeover"}, c)
+	assert.Equal(t, "permessage-deflate; server_no_context_takeover", resp)
+	assert.True(t, d.noContext)
+	assert.False(t, f.noContext)
+	resp, _, _ = negotiateDeflate([]string{"x-webkit-deflate-frame"}, c)
+	assert.Equal(t, "", resp)
+	c.ClientNoContextTakeover = true
+	resp, _, f = negotiateDeflate([]string{"permessage-deflate"}, c)
+	assert.Equal(t, "permessage-deflate; client_no_context_takeover", resp)
+	assert.True(t, f.noContext)
+}
+
+func TestDeflateMessage(t *testing.T) {
+	for _, noContext := range []bool{false, true} {
+		var (
+			out  bytes.Buffer
+			msgs = [][]byte{
+				[]byte(strings.Repeat("goim", 100)),
+				[]byte(strings.Repeat("goim", 100)),
+				{0, 1, 2},
+			}
+			wr = bufio.NewWriter(&out)
+			w  = newConn(nil, nil, wr)
+		)
+		w.deflater = &deflater{level: flate.BestSpeed, noContext: noContext}
+		for _, msg := range msgs {
+			assert.Nil(t, w.WriteHeader(BinaryMessage, len(msg)))
+			head, err := w.Peek(1)
+			assert.Nil(t, err)
+			head[0] = msg[0]
+			assert.Nil(t, w.WriteBody(msg[1:]))
+		}
+		assert.Nil(t, w.WriteMessage(PingMessage, nil))
+		assert.Nil(t, w.Flush())
+		r := newConn(nil, bufio.NewReader(&out), bufio.NewWriter(new(bytes.Buffer)))
+		r.inflater = &inflater{noContext: noContext}
+		for _, msg := range msgs {
+			op, b, err := r.ReadMessage()
+			assert.Nil(t, err)
+			assert.Equal(t, BinaryMessage, op)
+			assert.Equal(t, msg, b)
+		}
+	}
+}
+
+func TestInflateTooLarge(t *testing.T) {
+	d := &deflater{level: flate.BestSpeed, noContext: true}
+	b, err := d.compress([]byte(strings.Repeat("goim", 100)))
+	assert.Nil(t, err)
+	f := &inflater{noContext: true, max: 400}
+	_, err = f.decompress(append([]byte(nil), b...))
+	assert.Nil(t, err)
+	f.max = 399
+	_, err = f.decompress(append([]byte(nil), b...))
+	assert.Equal(t, ErrMessageTooLarge, err)
+}
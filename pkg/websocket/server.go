@@ -24,6 +24,16 @@ var (
 
 // Upgrade Switching Protocols
 func Upgrade(rwc io.ReadWriteCloser, rr *bufio.Reader, wr *bufio.Writer, req *Request) (conn *Conn, err error) {
+	return UpgradeWithDeflate(rwc, rr, wr, req, nil)
+}
+
+// UpgradeWithDeflate Switching Protocols, negotiate permessage-deflate if c is not nil.
+func UpgradeWithDeflate(rwc io.ReadWriteCloser, rr *bufio.Reader, wr *bufio.Writer, req *Request, c *DeflateConfig) (conn *Conn, err error) {
+	var (
+		ext string
+		d   *deflater
+		f   *inflater
+	)
 	if req.Method != "GET" {
 		return nil, ErrBadRequestMethod
 	}
@@ -41,11 +51,18 @@ func Upgrade(rwc io.ReadWriteCloser, rr *bufio.Reader, wr *bufio.Writer, req *Re
 		return nil, ErrChallengeResponse
 	}
 	_, _ = wr.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+	if c != nil {
+		if ext, d, f = negotiateDeflate(req.Header["Sec-Websocket-Extensions"], c); ext != "" {
+			_, _ = wr.WriteString("Sec-WebSocket-Extensions: " + ext + "\r\n")
+		}
+	}
 	_, _ = wr.WriteString("Sec-WebSocket-Accept: " + computeAcceptKey(challengeKey) + "\r\n\r\n")
 	if err = wr.Flush(); err != nil {
 		return
 	}
-	return newConn(rwc, rr, wr), nil
+	conn = newConn(rwc, rr, wr)
+	conn.deflater, conn.inflater = d, f
+	return
 }
 
 func computeAcceptKey(challengeKey string) string {